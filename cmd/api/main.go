@@ -1,51 +1,98 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"flash-cards/backend/internal/app"
+	"flash-cards/backend/internal/config"
 	"flash-cards/backend/internal/handler"
-	"flash-cards/backend/internal/repository"
-	"flash-cards/backend/internal/service"
+	"flash-cards/backend/internal/logging"
+)
 
-	"github.com/gorilla/mux"
-	"github.com/rs/cors"
+// Preenchidos no build com -ldflags "-X main.version=... -X main.commit=... -X main.buildTime=..."
+var (
+	version   = "dev"
+	commit    = ""
+	buildTime = ""
 )
 
 func main() {
-	// Inicialização dos repositórios
-	cardRepo := repository.NewCardRepository()
-	sessionRepo := repository.NewSessionRepository()
-
-	// Inicialização dos serviços
-	cardService := service.NewCardService(cardRepo)
-	sessionService := service.NewSessionService(sessionRepo, cardRepo)
-	websocketService := service.NewWebsocketService()
-
-	// Inicialização dos handlers
-	cardHandler := handler.NewCardHandler(cardService, websocketService)
-	sessionHandler := handler.NewSessionHandler(sessionService, websocketService)
-	websocketHandler := handler.NewWebsocketHandler(websocketService)
-
-	// Configuração do router
-	router := mux.NewRouter()
-
-	// Registro das rotas
-	cardHandler.RegisterRoutes(router)
-	sessionHandler.RegisterRoutes(router)
-	websocketHandler.RegisterRoutes(router)
-
-	// Configuração do CORS
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:5173", "http://127.0.0.1:5500"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "User-ID", "Authorization", "Origin"},
-		AllowCredentials: true,
-		Debug:            true,
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fatal(slog.Default(), "invalid configuration", err)
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.Log)
+	if err != nil {
+		fatal(slog.Default(), "invalid log configuration", err)
+	}
+	slog.SetDefault(logger)
+
+	application, err := app.New(app.Options{
+		Config: cfg,
+		Logger: logger,
+		Build: handler.BuildInfo{
+			Version:   version,
+			Commit:    commit,
+			BuildTime: buildTime,
+		},
 	})
+	if err != nil {
+		fatal(logger, "invalid server setup", err)
+	}
 
 	// Inicialização do servidor
-	handler := c.Handler(router)
-	log.Println("Server starting on port 3001...")
-	log.Fatal(http.ListenAndServe(":3001", handler))
+	server := &http.Server{
+		Addr:     cfg.Server.Addr,
+		Handler:  application,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("server starting", "addr", cfg.Server.Addr, "version", version, "debug", cfg.Admin.Token != "")
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal(logger, "server failed", err)
+	case <-stop.Done():
+	}
+	cancel()
+
+	// Desligamento: recusa novas sessões e conexões, avisa e fecha os hubs e, por
+	// fim, espera as requisições HTTP em andamento, tudo dentro do mesmo prazo.
+	logger.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancelShutdown()
+
+	if err := application.Shutdown(ctx); err != nil {
+		logger.Warn("websocket hubs did not finish in time", "error", err)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Warn("HTTP server did not finish in time", "error", err)
+		server.Close()
+	}
+	logger.Info("server stopped")
+}
+
+// fatal registra o erro e encerra o processo
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
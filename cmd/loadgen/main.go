@@ -0,0 +1,123 @@
+// Comando loadgen: gera carga contra uma instância do servidor para descobrir
+// quantas sessões e conexões ela suporta. Cria N sessões com M participantes
+// cada; os participantes entram, conectam o WebSocket e votam com tempos de
+// reflexão aleatórios, e o owner revela cada card. Ao final imprime os
+// percentis de latência das requisições e do fan-out (do envio de um voto ou
+// revelação até cada assinante recebê-lo) e a contagem de erros.
+//
+// Todo o tráfego sai de um só IP, então os limites por IP e de criação de
+// sessões do servidor precisam ser desativados ou aumentados, por exemplo com
+// rate 0 em rateLimits no arquivo de configuração. Com -debug-token, o estado
+// dos hubs em /debug/hubs é impresso junto com o relatório.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"flash-cards/backend/pkg/client"
+)
+
+func main() {
+	flags := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	server := flags.String("server", "http://localhost:3001", "API base URL")
+	sessions := flags.Int("sessions", 10, "number of concurrent sessions")
+	participants := flags.Int("participants", 8, "voting participants per session")
+	cards := flags.Int("cards", 5, "cards voted in each session")
+	thinkMin := flags.Duration("think-min", 500*time.Millisecond, "shortest time a participant takes to vote")
+	thinkMax := flags.Duration("think-max", 3*time.Second, "longest time a participant takes to vote")
+	ramp := flags.Duration("ramp", 2*time.Second, "period over which session starts are spread")
+	timeout := flags.Duration("timeout", 10*time.Second, "how long to wait for every subscriber to see a change")
+	duration := flags.Duration("duration", 0, "stop after this long; 0 runs every session to the end")
+	full := flags.Bool("full", false, "subscribe with full session updates instead of patches")
+	debugToken := flags.String("debug-token", "", "admin token used to fetch /debug/hubs after the run")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	if *sessions < 1 || *participants < 1 || *cards < 1 || *thinkMax < *thinkMin {
+		fmt.Fprintln(os.Stderr, "loadgen: sessions, participants and cards must be positive and think-max at least think-min")
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if *duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	// Cada participante simulado usa uma conexão própria, como clientes reais
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 0
+	transport.MaxIdleConnsPerHost = *sessions * (*participants + 1)
+	api := client.New(*server, client.WithHTTPClient(&http.Client{Transport: transport, Timeout: 30 * time.Second}))
+
+	opts := Options{
+		Participants: *participants,
+		Cards:        *cards,
+		ThinkMin:     *thinkMin,
+		ThinkMax:     *thinkMax,
+		Timeout:      *timeout,
+		Full:         *full,
+	}
+	stats := NewStats()
+
+	fmt.Printf("loadgen: %d sessions x %d participants, %d cards each, against %s\n", *sessions, *participants, *cards, *server)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < *sessions; i++ {
+		delay := time.Duration(0)
+		if *sessions > 1 {
+			delay = *ramp * time.Duration(i) / time.Duration(*sessions-1)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case <-time.After(delay):
+				runSession(ctx, api, i, opts, stats)
+			case <-ctx.Done():
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Printf("finished in %s\n\n", time.Since(start).Round(time.Millisecond))
+	stats.Report(os.Stdout)
+	if *debugToken != "" {
+		printHubs(*server, *debugToken, os.Stdout)
+	}
+}
+
+// printHubs imprime o estado dos hubs que o servidor expõe na área de debug
+func printHubs(server, token string, out io.Writer) {
+	req, err := http.NewRequest(http.MethodGet, server+"/debug/hubs", nil)
+	if err != nil {
+		fmt.Fprintln(out, "\nhubs:", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(out, "\nhubs:", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	fmt.Fprintf(out, "\nhubs (%s):\n", resp.Status)
+	io.Copy(out, resp.Body)
+	fmt.Fprintln(out)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"flash-cards/backend/pkg/client"
+
+	"github.com/gorilla/websocket"
+)
+
+var errTimeout = errors.New("timed out waiting for the event")
+
+// deck são os valores votados, como num baralho de planning poker
+var deck = []int{1, 2, 3, 5, 8, 13}
+
+// Options controla o cenário de cada sessão
+type Options struct {
+	Participants int
+	Cards        int
+	ThinkMin     time.Duration
+	ThinkMax     time.Duration
+	// Timeout é quanto se espera para todos os assinantes verem um voto ou uma revelação
+	Timeout time.Duration
+	Full    bool
+}
+
+// mark identifica uma mudança observável de um card: o voto de número votes
+// ou, com revealed, a revelação
+type mark struct {
+	card     string
+	votes    int
+	revealed bool
+}
+
+// fanout relaciona o envio de cada mudança com o momento em que cada assinante
+// da sessão a recebeu
+type fanout struct {
+	mutex   sync.Mutex
+	sent    map[mark]time.Time
+	seen    map[mark][]time.Time
+	changed chan struct{}
+}
+
+func newFanout() *fanout {
+	return &fanout{
+		sent:    map[mark]time.Time{},
+		seen:    map[mark][]time.Time{},
+		changed: make(chan struct{}),
+	}
+}
+
+func (f *fanout) send(m mark, at time.Time) {
+	f.mutex.Lock()
+	f.sent[m] = at
+	f.mutex.Unlock()
+}
+
+func (f *fanout) observe(m mark, at time.Time) {
+	f.mutex.Lock()
+	f.seen[m] = append(f.seen[m], at)
+	close(f.changed)
+	f.changed = make(chan struct{})
+	f.mutex.Unlock()
+}
+
+// wait aguarda até n assinantes terem visto a mudança
+func (f *fanout) wait(ctx context.Context, m mark, n int, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		f.mutex.Lock()
+		count, changed := len(f.seen[m]), f.changed
+		f.mutex.Unlock()
+		if count >= n {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return fmt.Errorf("%w: %d of %d subscribers", errTimeout, count, n)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// report registra a latência entre o envio e cada recebimento. O envio é medido
+// no início da requisição HTTP, então a latência inclui a própria requisição.
+func (f *fanout) report(stats *Stats) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for m, times := range f.seen {
+		sent, ok := f.sent[m]
+		if !ok {
+			continue
+		}
+		metric := "fanout_vote"
+		if m.revealed {
+			metric = "fanout_reveal"
+		}
+		for _, at := range times {
+			stats.Observe(metric, at.Sub(sent))
+		}
+	}
+}
+
+// subscriber acompanha o que um participante já viu de cada card
+type subscriber struct {
+	fanout *fanout
+	cards  map[string]mark
+}
+
+// consume lê os eventos até a assinatura terminar; ready é fechado no primeiro
+func (s *subscriber) consume(sub *client.Subscription, ready chan<- struct{}) {
+	first := true
+	for event := range sub.Events {
+		now := time.Now()
+		if first {
+			close(ready)
+			first = false
+		}
+
+		var cards []client.Card
+		switch event.Type {
+		case client.EventSnapshot:
+			if snapshot, err := event.Snapshot(); err == nil {
+				cards = snapshot.Session.Cards
+			}
+		case client.EventSessionUpdate:
+			if session, err := event.Session(); err == nil {
+				cards = session.Cards
+			}
+		case client.EventCardUpdate:
+			if card, err := event.Card(); err == nil {
+				cards = []client.Card{card}
+			}
+		case client.EventCardsUpdated:
+			cards, _ = event.Cards()
+		}
+		for _, card := range cards {
+			s.update(card, now)
+		}
+	}
+}
+
+// update marca como vistos os votos novos e a revelação. Com o agrupamento do
+// hub, um evento pode trazer vários votos de uma vez.
+func (s *subscriber) update(card client.Card, at time.Time) {
+	previous := s.cards[card.ID]
+	for votes := previous.votes + 1; votes <= len(card.Votes); votes++ {
+		s.fanout.observe(mark{card: card.ID, votes: votes}, at)
+	}
+	if card.Closed && !previous.revealed {
+		s.fanout.observe(mark{card: card.ID, revealed: true}, at)
+	}
+	s.cards[card.ID] = mark{votes: max(previous.votes, len(card.Votes)), revealed: card.Closed}
+}
+
+// runSession executa o cenário de uma sessão: o owner cria a sessão, os
+// participantes entram e conectam o WebSocket e, para cada card, votam depois
+// de um tempo de reflexão; o owner revela quando todos viram todos os votos.
+func runSession(ctx context.Context, api *client.Client, index int, opts Options, stats *Stats) {
+	var created *client.CreatedSession
+	err := stats.Time("create_session", func() (err error) {
+		created, err = api.CreateSession(ctx, fmt.Sprintf("owner-%d", index), "")
+		return err
+	})
+	if err != nil {
+		return
+	}
+	code := created.Code
+	owner := api.WithToken(created.Token)
+
+	// Entrada dos participantes, em paralelo
+	voters := make([]*client.Client, opts.Participants)
+	var joins sync.WaitGroup
+	for i := range voters {
+		joins.Add(1)
+		go func(i int) {
+			defer joins.Done()
+			stats.Time("join", func() error {
+				participant, err := api.JoinSession(ctx, code, fmt.Sprintf("p%d", i+1))
+				if err == nil {
+					voters[i] = api.WithToken(participant.Token)
+				}
+				return err
+			})
+		}(i)
+	}
+	joins.Wait()
+
+	// O owner também assina, como a tela do facilitador
+	fan := newFanout()
+	var subs []*client.Subscription
+	var ready []chan struct{}
+	for _, member := range append([]*client.Client{owner}, voters...) {
+		if member == nil {
+			continue
+		}
+		var sub *client.Subscription
+		err := stats.Time("ws_connect", func() (err error) {
+			sub, err = member.Subscribe(ctx, code, client.SubscribeOptions{Full: opts.Full})
+			return err
+		})
+		if err != nil {
+			continue
+		}
+		subs = append(subs, sub)
+		ch := make(chan struct{})
+		ready = append(ready, ch)
+		go (&subscriber{fanout: fan, cards: map[string]mark{}}).consume(sub, ch)
+	}
+	defer func() {
+		for _, sub := range subs {
+			sub.Close()
+			if err := sub.Err(); err != nil && !websocket.IsCloseError(err, client.CloseSessionClosed) {
+				stats.Error("ws", err)
+			}
+		}
+		fan.report(stats)
+	}()
+
+	for _, ch := range ready {
+		select {
+		case <-ch:
+		case <-time.After(opts.Timeout):
+			stats.Error("ws_snapshot", errTimeout)
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	for round := 0; round < opts.Cards && ctx.Err() == nil; round++ {
+		playRound(ctx, owner, voters, code, round, len(subs), fan, opts, stats)
+	}
+	if ctx.Err() == nil {
+		stats.Time("close_session", func() error { return owner.CloseSession(ctx, code) })
+	}
+}
+
+// playRound cria um card, coleta os votos e o revela
+func playRound(ctx context.Context, owner *client.Client, voters []*client.Client, code string, round, subscribers int, fan *fanout, opts Options, stats *Stats) {
+	var card *client.Card
+	err := stats.Time("create_card", func() (err error) {
+		card, err = owner.CreateCard(ctx, code, fmt.Sprintf("Story %d", round+1), "")
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	var votes sync.WaitGroup
+	var mutex sync.Mutex
+	counted := 0
+	for _, voter := range voters {
+		if voter == nil {
+			continue
+		}
+		votes.Add(1)
+		go func(voter *client.Client) {
+			defer votes.Done()
+			select {
+			case <-time.After(think(opts)):
+			case <-ctx.Done():
+				return
+			}
+
+			start := time.Now()
+			err := stats.Time("vote", func() error {
+				voted, err := voter.Vote(ctx, code, card.ID, deck[rand.Intn(len(deck))])
+				if err == nil {
+					fan.send(mark{card: card.ID, votes: len(voted.Votes)}, start)
+				}
+				return err
+			})
+			if err == nil {
+				mutex.Lock()
+				counted++
+				mutex.Unlock()
+			}
+		}(voter)
+	}
+	votes.Wait()
+
+	if counted > 0 {
+		if err := fan.wait(ctx, mark{card: card.ID, votes: counted}, subscribers, opts.Timeout); err != nil {
+			stats.Error("fanout_vote", err)
+		}
+	}
+
+	start := time.Now()
+	err = stats.Time("reveal", func() error {
+		_, err := owner.Reveal(ctx, code, card.ID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+	fan.send(mark{card: card.ID, revealed: true}, start)
+	if err := fan.wait(ctx, mark{card: card.ID, revealed: true}, subscribers, opts.Timeout); err != nil {
+		stats.Error("fanout_reveal", err)
+	}
+}
+
+// think sorteia o tempo de reflexão de um voto
+func think(opts Options) time.Duration {
+	spread := opts.ThinkMax - opts.ThinkMin
+	if spread <= 0 {
+		return opts.ThinkMin
+	}
+	return opts.ThinkMin + time.Duration(rand.Int63n(int64(spread)))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"flash-cards/backend/pkg/client"
+)
+
+// Stats acumula latências por métrica e erros por operação e código. É seguro
+// para uso concorrente.
+type Stats struct {
+	mutex     sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		latencies: map[string][]time.Duration{},
+		errors:    map[string]int{},
+	}
+}
+
+// Observe registra uma latência da métrica informada
+func (s *Stats) Observe(metric string, latency time.Duration) {
+	s.mutex.Lock()
+	s.latencies[metric] = append(s.latencies[metric], latency)
+	s.mutex.Unlock()
+}
+
+// Time registra a duração de fn na métrica op, ou o erro retornado por ela
+func (s *Stats) Time(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	if err != nil {
+		s.Error(op, err)
+		return err
+	}
+	s.Observe(op, time.Since(start))
+	return nil
+}
+
+// Error conta um erro da operação, agrupado pelo código da API quando houver.
+// Requisições canceladas pelo fim do teste não contam.
+func (s *Stats) Error(op string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	key := op + " " + errorKind(err)
+	s.mutex.Lock()
+	s.errors[key]++
+	s.mutex.Unlock()
+}
+
+func errorKind(err error) string {
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Code
+	case errors.Is(err, errTimeout):
+		return "TIMEOUT"
+	default:
+		return "NETWORK"
+	}
+}
+
+// Report escreve os percentis de cada métrica e a contagem de erros
+func (s *Stats) Report(out io.Writer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	metrics := make([]string, 0, len(s.latencies))
+	for metric := range s.latencies {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "metric\tcount\tp50\tp90\tp99\tmax\t")
+	for _, metric := range metrics {
+		values := s.latencies[metric]
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t\n", metric, len(values),
+			formatLatency(percentile(values, 50)),
+			formatLatency(percentile(values, 90)),
+			formatLatency(percentile(values, 99)),
+			formatLatency(values[len(values)-1]))
+	}
+	w.Flush()
+
+	if len(s.errors) == 0 {
+		fmt.Fprintln(out, "\nno errors")
+		return
+	}
+	keys := make([]string, 0, len(s.errors))
+	total := 0
+	for key, count := range s.errors {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(out, "\n%d errors\n", total)
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s\t%d\n", key, s.errors[key])
+	}
+	w.Flush()
+}
+
+// percentile usa o método nearest-rank sobre valores já ordenados
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// CardInput é um card lido do arquivo de importação
+type CardInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// readCards lê os cards de acordo com a extensão do arquivo:
+//   - .json: lista de objetos {"title", "description"}
+//   - .csv: colunas title e description, com cabeçalho opcional
+//   - outros: um título por linha; o texto após " | " vira a descrição. Linhas
+//     em branco e iniciadas por # são ignoradas.
+func readCards(name string, r io.Reader) ([]CardInput, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		var cards []CardInput
+		if err := json.NewDecoder(r).Decode(&cards); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		return cards, nil
+	case ".csv":
+		return readCSVCards(name, r)
+	default:
+		return readTextCards(r)
+	}
+}
+
+func readCSVCards(name string, r io.Reader) ([]CardInput, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "title") {
+		records = records[1:]
+	}
+
+	var cards []CardInput
+	for _, record := range records {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		card := CardInput{Title: record[0]}
+		if len(record) > 1 {
+			card.Description = record[1]
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+func readTextCards(r io.Reader) ([]CardInput, error) {
+	var cards []CardInput
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		title, description, _ := strings.Cut(line, " | ")
+		cards = append(cards, CardInput{Title: title, Description: description})
+	}
+	return cards, scanner.Err()
+}
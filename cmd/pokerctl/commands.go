@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"flash-cards/backend/pkg/client"
+)
+
+func runCreate(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	name := flags.String("name", "", "owner name (required)")
+	code := flags.String("code", "", "custom session code, if the server allows it")
+	if err := parse(flags, args, 0, 0, "-name NAME [-code CODE]"); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("create: -name is required")
+	}
+
+	created, err := a.api.CreateSession(ctx, *name, *code)
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(Credential{
+		Server: a.server,
+		Code:   created.Code,
+		UserID: created.Session.OwnerID,
+		Name:   *name,
+		Role:   string(client.RoleOwner),
+		Token:  created.Token,
+	}); err != nil {
+		return fmt.Errorf("session %s created, but storing its credentials failed: %w", created.Code, err)
+	}
+
+	fmt.Fprintln(a.out, created.Code)
+	return nil
+}
+
+func runJoin(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("join", flag.ContinueOnError)
+	name := flags.String("name", "", "participant name (required)")
+	force := flags.Bool("force", false, "replace credentials already stored for the session")
+	if err := parse(flags, args, 1, 1, "[-force] -name NAME CODE"); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("join: -name is required")
+	}
+	code := flags.Arg(0)
+
+	// Só há uma credencial por sessão; entrar de novo trocaria, por exemplo, o
+	// token do owner pelo de um convidado
+	if existing, err := a.store.Get(a.server, code); err == nil && !*force {
+		return fmt.Errorf("already in session %s as %s (%s); use -force to replace", code, existing.Name, existing.Role)
+	}
+
+	participant, err := a.api.JoinSession(ctx, code, *name)
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(Credential{
+		Server: a.server,
+		Code:   code,
+		UserID: participant.ID,
+		Name:   participant.Name,
+		Role:   string(participant.Role),
+		Token:  participant.Token,
+	}); err != nil {
+		return fmt.Errorf("joined %s, but storing the credentials failed: %w", code, err)
+	}
+
+	fmt.Fprintf(a.out, "joined %s as %s (%s)\n", code, participant.Name, participant.ID)
+	return nil
+}
+
+func runImport(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	if err := parse(flags, args, 2, 2, "CODE FILE"); err != nil {
+		return err
+	}
+	code, name := flags.Arg(0), flags.Arg(1)
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	cards, err := readCards(name, input)
+	if err != nil {
+		return err
+	}
+
+	// Cria um card por vez, na ordem do arquivo; em caso de erro informa quantos
+	// já foram criados, para que o restante possa ser importado depois
+	for i, card := range cards {
+		created, err := api.CreateCard(ctx, code, card.Title, card.Description)
+		if err != nil {
+			return fmt.Errorf("card %d (%q): %w; %d of %d cards imported", i+1, card.Title, err, i, len(cards))
+		}
+		fmt.Fprintf(a.out, "%s\t%s\n", created.ID, created.Title)
+	}
+	return nil
+}
+
+func runParticipants(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("participants", flag.ContinueOnError)
+	if err := parse(flags, args, 1, 1, "CODE"); err != nil {
+		return err
+	}
+	code := flags.Arg(0)
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+	session, err := api.GetSession(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tROLE\tJOINED")
+	for _, user := range session.Users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", user.ID, user.Name, user.Role, user.JoinedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+func runCards(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("cards", flag.ContinueOnError)
+	if err := parse(flags, args, 1, 1, "CODE"); err != nil {
+		return err
+	}
+	code := flags.Arg(0)
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+	cards, err := api.ListCards(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tSTATUS\tVOTES\tAVERAGE")
+	for _, card := range cards {
+		status, average := "open", "-"
+		if card.Closed {
+			status, average = "revealed", strconv.FormatFloat(card.Result.Average, 'f', 1, 64)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", card.ID, card.Title, status, len(card.Votes), average)
+	}
+	return w.Flush()
+}
+
+func runReveal(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("reveal", flag.ContinueOnError)
+	all := flags.Bool("all", false, "reveal every open card")
+	if err := parse(flags, args, 1, -1, "[-all] CODE [CARD_ID...]"); err != nil {
+		return err
+	}
+	code, ids := flags.Arg(0), flags.Args()[1:]
+	if *all == (len(ids) > 0) {
+		return fmt.Errorf("reveal: pass either card IDs or -all")
+	}
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+	if *all {
+		cards, err := api.ListCards(ctx, code)
+		if err != nil {
+			return err
+		}
+		for _, card := range cards {
+			if !card.Closed {
+				ids = append(ids, card.ID)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		card, err := api.Reveal(ctx, code, id)
+		if err != nil {
+			return fmt.Errorf("card %s: %w", id, err)
+		}
+		fmt.Fprintf(a.out, "%s\t%s\taverage %.1f\t%s\n", card.ID, card.Title, card.Result.Average, formatDistribution(card.Result.Distribution))
+	}
+	return nil
+}
+
+func runClose(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("close", flag.ContinueOnError)
+	if err := parse(flags, args, 1, 1, "CODE"); err != nil {
+		return err
+	}
+	code := flags.Arg(0)
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+	return api.CloseSession(ctx, code)
+}
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "json", "json or csv")
+	output := flags.String("o", "-", "output file, - for stdout")
+	if err := parse(flags, args, 1, 1, "[-format json|csv] [-o FILE] CODE"); err != nil {
+		return err
+	}
+	code := flags.Arg(0)
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("export: unknown format %q", *format)
+	}
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+	session, err := api.GetSession(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	out := a.out
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if *format == "csv" {
+		return writeCSV(out, session)
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(session)
+}
+
+// writeCSV escreve uma linha por card; votos e distribuição vão como texto
+func writeCSV(out io.Writer, session *client.Session) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"id", "title", "description", "revealed", "votes", "average", "distribution"})
+	for _, card := range session.Cards {
+		votes := make([]string, len(card.Votes))
+		for i, vote := range card.Votes {
+			votes[i] = strconv.Itoa(vote)
+		}
+		average := ""
+		if card.Closed {
+			average = strconv.FormatFloat(card.Result.Average, 'f', -1, 64)
+		}
+		w.Write([]string{
+			card.ID,
+			card.Title,
+			card.Description,
+			strconv.FormatBool(card.Closed),
+			strings.Join(votes, " "),
+			average,
+			formatDistribution(card.Result.Distribution),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func runSessions(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	if err := parse(flags, args, 0, 0, ""); err != nil {
+		return err
+	}
+
+	credentials, err := a.store.List()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tCODE\tNAME\tROLE")
+	for _, credential := range credentials {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", credential.Server, credential.Code, credential.Name, credential.Role)
+	}
+	return w.Flush()
+}
+
+func runForget(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("forget", flag.ContinueOnError)
+	if err := parse(flags, args, 1, 1, "CODE"); err != nil {
+		return err
+	}
+	return a.store.Delete(a.server, flags.Arg(0))
+}
+
+// formatDistribution escreve a distribuição como "3:2 5:1" (valor:votos), em ordem de valor
+func formatDistribution(distribution map[int]int) string {
+	scores := make([]int, 0, len(distribution))
+	for score := range distribution {
+		scores = append(scores, score)
+	}
+	sort.Ints(scores)
+
+	parts := make([]string, len(scores))
+	for i, score := range scores {
+		parts[i] = fmt.Sprintf("%d:%d", score, distribution[score])
+	}
+	return strings.Join(parts, " ")
+}
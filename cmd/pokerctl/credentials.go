@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Credential é o token de um participante numa sessão, guardado por create e join
+type Credential struct {
+	Server string `json:"server"`
+	Code   string `json:"code"`
+	UserID string `json:"userId"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Token  string `json:"token"`
+}
+
+// CredentialStore guarda as credenciais num arquivo JSON legível apenas pelo
+// usuário, uma por servidor e sessão
+type CredentialStore struct {
+	path string
+}
+
+// NewCredentialStore usa o arquivo informado ou, se vazio, POKERCTL_CREDENTIALS
+// ou credentials.json no diretório de configuração do usuário
+func NewCredentialStore(path string) (*CredentialStore, error) {
+	if path == "" {
+		path = os.Getenv("POKERCTL_CREDENTIALS")
+	}
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("locating config directory: %w", err)
+		}
+		path = filepath.Join(dir, "pokerctl", "credentials.json")
+	}
+	return &CredentialStore{path: path}, nil
+}
+
+func credentialKey(server, code string) string {
+	return strings.TrimRight(server, "/") + " " + strings.ToUpper(code)
+}
+
+// Get retorna a credencial da sessão no servidor
+func (s *CredentialStore) Get(server, code string) (Credential, error) {
+	credentials, err := s.load()
+	if err != nil {
+		return Credential{}, err
+	}
+	credential, ok := credentials[credentialKey(server, code)]
+	if !ok {
+		return Credential{}, fmt.Errorf("no credentials for session %s on %s; run create or join first", code, server)
+	}
+	return credential, nil
+}
+
+// Put grava a credencial, substituindo a anterior da mesma sessão
+func (s *CredentialStore) Put(credential Credential) error {
+	credentials, err := s.load()
+	if err != nil {
+		return err
+	}
+	credentials[credentialKey(credential.Server, credential.Code)] = credential
+	return s.save(credentials)
+}
+
+// Delete remove a credencial da sessão; não é erro se ela não existir
+func (s *CredentialStore) Delete(server, code string) error {
+	credentials, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(credentials, credentialKey(server, code))
+	return s.save(credentials)
+}
+
+// List retorna todas as credenciais, ordenadas por servidor e código
+func (s *CredentialStore) List() ([]Credential, error) {
+	credentials, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Credential, 0, len(credentials))
+	for _, credential := range credentials {
+		list = append(list, credential)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return credentialKey(list[i].Server, list[i].Code) < credentialKey(list[j].Server, list[j].Code)
+	})
+	return list, nil
+}
+
+func (s *CredentialStore) load() (map[string]Credential, error) {
+	credentials := map[string]Credential{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return credentials, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.path, err)
+	}
+	return credentials, nil
+}
+
+// save grava num arquivo temporário e renomeia, para nunca deixar o arquivo pela metade
+func (s *CredentialStore) save(credentials map[string]Credential) error {
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
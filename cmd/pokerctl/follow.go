@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"flash-cards/backend/pkg/client"
+
+	"github.com/gorilla/websocket"
+)
+
+func runFollow(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("follow", flag.ContinueOnError)
+	full := flags.Bool("full", false, "receive full session updates instead of patches")
+	raw := flags.Bool("raw", false, "print each event as a JSON line")
+	if err := parse(flags, args, 1, 1, "[-full] [-raw] CODE"); err != nil {
+		return err
+	}
+	code := flags.Arg(0)
+
+	api, err := a.session(code)
+	if err != nil {
+		return err
+	}
+	sub, err := api.Subscribe(ctx, code, client.SubscribeOptions{Full: *full})
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	// Segue até Ctrl+C (contexto cancelado, Err nil) ou até o servidor encerrar a
+	// assinatura, como ao fechar a sessão
+	for event := range sub.Events {
+		if *raw {
+			line, _ := json.Marshal(event)
+			fmt.Fprintln(a.out, string(line))
+			continue
+		}
+		printEvent(a.out, event)
+	}
+
+	if err := sub.Err(); !websocket.IsCloseError(err, client.CloseSessionClosed) {
+		return err
+	}
+	fmt.Fprintln(a.out, "session closed")
+	return nil
+}
+
+// printEvent escreve uma linha legível por evento
+func printEvent(out io.Writer, event client.Event) {
+	fmt.Fprintf(out, "%s #%d %-17s %s\n", time.Now().Format(time.TimeOnly), event.Seq, event.Type, describeEvent(event))
+}
+
+func describeEvent(event client.Event) string {
+	switch event.Type {
+	case client.EventSnapshot:
+		if snapshot, err := event.Snapshot(); err == nil {
+			return fmt.Sprintf("%s, %d participants, %d cards, %d connected",
+				snapshot.Session.State, len(snapshot.Session.Users), len(snapshot.Session.Cards), snapshot.Presence.Connected)
+		}
+	case client.EventSessionUpdate:
+		if session, err := event.Session(); err == nil {
+			return fmt.Sprintf("%s, version %d", session.State, session.Version)
+		}
+	case client.EventSessionPatch:
+		if patch, err := event.Patch(); err == nil {
+			return fmt.Sprintf("version %d -> %d, %d changes", patch.BaseVersion, patch.Version, len(patch.Ops))
+		}
+	case client.EventCardUpdate:
+		if card, err := event.Card(); err == nil {
+			return describeCard(card)
+		}
+	case client.EventCardsUpdated:
+		if cards, err := event.Cards(); err == nil {
+			return fmt.Sprintf("%d cards updated", len(cards))
+		}
+	case client.EventUserUpdate:
+		if update, err := event.UserUpdate(); err == nil {
+			return fmt.Sprintf("%s %s (%s)", update.Action, update.User.Name, update.User.Role)
+		}
+	case client.EventServerRestarting:
+		if notice, err := event.ServerRestarting(); err == nil {
+			return fmt.Sprintf("reconnecting in %dms", notice.ReconnectAfterMs)
+		}
+	}
+	return string(event.Data)
+}
+
+func describeCard(card client.Card) string {
+	if card.Closed {
+		return fmt.Sprintf("%q revealed, average %.1f (%s)", card.Title, card.Result.Average, formatDistribution(card.Result.Distribution))
+	}
+	return fmt.Sprintf("%q, %d votes", card.Title, len(card.Votes))
+}
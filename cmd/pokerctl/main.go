@@ -0,0 +1,146 @@
+// Comando pokerctl: prepara e acompanha sessões de planning poker pelo
+// terminal, usando a mesma API dos demais clientes (pkg/client). Os tokens
+// obtidos em create e join ficam guardados localmente, por servidor e sessão.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"flash-cards/backend/pkg/client"
+)
+
+const usage = `usage: pokerctl [global flags] <command> [flags] [args]
+
+Commands:
+  create -name NAME [-code CODE]         create a session and store the owner token
+  join [-force] -name NAME CODE          join a session as a guest and store the token
+  import CODE FILE                       create cards from a .json, .csv or text file
+  participants CODE                      list participants and their roles
+  cards CODE                             list cards with their status and results
+  reveal [-all] CODE [CARD_ID...]        reveal votes and close the given cards
+  close CODE                             close the session
+  follow [-full] [-raw] CODE             print live session events until interrupted
+  export [-format json|csv] [-o FILE] CODE
+                                         export participants, cards, votes and results
+  sessions                               list stored credentials
+  forget CODE                            remove the stored credentials of a session
+
+Global flags:
+`
+
+// app reúne o que os comandos compartilham
+type app struct {
+	server string
+	api    *client.Client
+	store  *CredentialStore
+	out    io.Writer
+}
+
+// command executa um subcomando com seus argumentos
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"create":       runCreate,
+	"join":         runJoin,
+	"import":       runImport,
+	"participants": runParticipants,
+	"cards":        runCards,
+	"reveal":       runReveal,
+	"close":        runClose,
+	"follow":       runFollow,
+	"export":       runExport,
+	"sessions":     runSessions,
+	"forget":       runForget,
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	cancel()
+
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pokerctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("pokerctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	server := flags.String("server", envOr("POKERCTL_SERVER", "http://localhost:3001"), "API base URL (env POKERCTL_SERVER)")
+	credentials := flags.String("credentials", "", "credentials file (env POKERCTL_CREDENTIALS, default in the user config dir)")
+	language := flags.String("lang", "", "language for error messages, such as en or pt-BR")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	store, err := NewCredentialStore(*credentials)
+	if err != nil {
+		return err
+	}
+	a := &app{
+		server: strings.TrimRight(*server, "/"),
+		api:    client.New(*server, client.WithLanguage(*language)),
+		store:  store,
+		out:    stdout,
+	}
+	return cmd(ctx, a, flags.Args()[1:])
+}
+
+// session retorna um cliente autenticado com a credencial guardada da sessão
+func (a *app) session(code string) (*client.Client, error) {
+	credential, err := a.store.Get(a.server, code)
+	if err != nil {
+		return nil, err
+	}
+	return a.api.WithToken(credential.Token), nil
+}
+
+// parse interpreta as flags de um subcomando e confere o número de argumentos
+func parse(flags *flag.FlagSet, args []string, minArgs, maxArgs int, synopsis string) error {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: pokerctl %s %s\n", flags.Name(), synopsis)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < minArgs || (maxArgs >= 0 && flags.NArg() > maxArgs) {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	return nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,177 @@
+// Package app monta o servidor completo (repositórios, serviços, handlers e
+// middlewares) como um http.Handler. É usado pelo cmd/api e pelos testes, que
+// podem trocar o armazenamento, o relógio e a fonte de aleatoriedade.
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/config"
+	"flash-cards/backend/internal/handler"
+	"flash-cards/backend/internal/logging"
+	"flash-cards/backend/internal/metrics"
+	"flash-cards/backend/internal/openapi"
+	"flash-cards/backend/internal/random"
+	"flash-cards/backend/internal/repository"
+	"flash-cards/backend/internal/service"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+// Options são as dependências do servidor. Apenas Config é obrigatória; os
+// demais campos têm padrões de produção quando vazios.
+type Options struct {
+	Config config.Config
+	// Logger padrão: descarta os logs
+	Logger *slog.Logger
+	Build  handler.BuildInfo
+
+	// Sessions e Cards padrão: repositórios em memória novos, com o gerador de
+	// códigos de Config.Sessions.Codes, IDs e Clock
+	Sessions service.SessionStore
+	Cards    service.CardStore
+
+	// Clock padrão: clock.System
+	Clock clock.Clock
+	// Random sorteia os códigos de sessão e gera a chave dos tokens, quando
+	// Config.Token.Secret está vazio, e os IDs dos tokens. Padrão: random.Crypto.
+	Random random.Source
+	// IDs gera os IDs de sessões, participantes, cards, requisições e conexões.
+	// Padrão: UUIDs gerados com Random.
+	IDs random.IDGenerator
+}
+
+// App é o servidor montado. Atende requisições como http.Handler; Shutdown
+// encerra os hubs antes de o servidor HTTP parar.
+type App struct {
+	handler          http.Handler
+	shutdownGate     *handler.ShutdownGate
+	websocketService *service.WebsocketService
+	reconnectAfter   time.Duration
+}
+
+// New valida as dependências e monta o servidor
+func New(opts Options) (*App, error) {
+	cfg := opts.Config
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.System
+	}
+	if opts.Random == nil {
+		opts.Random = random.Crypto
+	}
+	if opts.IDs == nil {
+		opts.IDs = random.NewIDGenerator(opts.Random)
+	}
+
+	// Inicialização dos repositórios
+	cardRepo := opts.Cards
+	if cardRepo == nil {
+		cardRepo = repository.NewCardRepository(opts.IDs)
+	}
+	sessionRepo := opts.Sessions
+	if sessionRepo == nil {
+		codes, err := random.NewCodeGenerator(cfg.Sessions.Codes, opts.Random)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session code configuration: %w", err)
+		}
+		sessionRepo = repository.NewSessionRepository(codes, opts.IDs, opts.Clock)
+	}
+
+	key, err := tokenKey(logger, cfg.Token.Secret, opts.Random)
+	if err != nil {
+		return nil, err
+	}
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	// Inicialização dos serviços
+	cardService := service.NewCardService(cardRepo)
+	sessionService := service.NewSessionService(sessionRepo, cardRepo, cfg.Sessions.AllowVanityCodes, opts.Clock, opts.IDs, logger)
+	websocketService := service.NewWebsocketService(sessionService, cfg.HubOptions(), opts.Clock, opts.IDs, logger)
+	tokenService := service.NewTokenService(key, time.Duration(cfg.Token.TTL), opts.Clock, opts.Random)
+
+	// Inicialização dos handlers
+	cardHandler := handler.NewCardHandler(cardService, tokenService)
+	sessionHandler := handler.NewSessionHandler(sessionService, websocketService, tokenService)
+	websocketHandler := handler.NewWebsocketHandler(websocketService, tokenService)
+	requestLogger := handler.NewRequestLogger(logger, opts.IDs, opts.Clock)
+	rateLimiter := handler.NewRateLimiter(cfg.RateLimits, tokenService, opts.Clock)
+	shutdownGate := handler.NewShutdownGate(time.Duration(cfg.Server.ReconnectAfter))
+	healthHandler := handler.NewHealthHandler(sessionService, websocketService, shutdownGate)
+	debugHandler := handler.NewDebugHandler(cfg.Admin.Token, websocketService, opts.Build, opts.Clock)
+	requestValidator := handler.NewRequestValidator(validator, cfg.Server.MaxBodyBytes)
+	metricsHandler := handler.NewMetrics(metrics.NewRegistry(), sessionService, websocketService, rateLimiter, opts.Clock)
+
+	// Configuração do router
+	router := mux.NewRouter()
+	router.Use(requestLogger.Middleware)
+	router.Use(metricsHandler.Middleware)
+	router.Use(shutdownGate.Middleware)
+	router.Use(rateLimiter.Middleware)
+	router.Use(requestValidator.Middleware)
+
+	// Registro das rotas
+	handler.RegisterAPI(router, cardHandler, sessionHandler, websocketHandler)
+	metricsHandler.RegisterRoutes(router)
+	healthHandler.RegisterRoutes(router)
+	debugHandler.RegisterRoutes(router)
+
+	// Configuração do CORS
+	c := cors.New(cors.Options{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "Origin", "If-Match", "X-Request-ID"},
+		ExposedHeaders:   []string{"ETag", "Retry-After", "X-Request-ID", "Deprecation", "Link"},
+		AllowCredentials: true,
+		Debug:            cfg.CORS.Debug,
+		Logger:           logging.PrintfLogger{Logger: logger.With("component", "cors"), Level: slog.LevelDebug},
+	})
+
+	return &App{
+		handler:          c.Handler(router),
+		shutdownGate:     shutdownGate,
+		websocketService: websocketService,
+		reconnectAfter:   time.Duration(cfg.Server.ReconnectAfter),
+	}, nil
+}
+
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.handler.ServeHTTP(w, r)
+}
+
+// Shutdown recusa novas sessões e conexões, avisa e fecha os hubs e aguarda
+// que terminem, ou o fim de ctx. As requisições HTTP em andamento ficam com o
+// http.Server. Os repositórios são em memória, então não há armazenamento a
+// descarregar.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.shutdownGate.Drain()
+	return a.websocketService.Shutdown(ctx, a.reconnectAfter)
+}
+
+// tokenKey retorna a chave HMAC configurada. Sem ela, gera uma chave aleatória:
+// os tokens deixam de valer quando o servidor reinicia.
+func tokenKey(logger *slog.Logger, secret string, entropy io.Reader) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+
+	logger.Warn("token secret not set, using a random key")
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(entropy, key); err != nil {
+		return nil, fmt.Errorf("generating token key: %w", err)
+	}
+	return key, nil
+}
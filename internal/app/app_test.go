@@ -0,0 +1,788 @@
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"flash-cards/backend/internal/app"
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/config"
+	"flash-cards/backend/internal/jsonpatch"
+	"flash-cards/backend/internal/random"
+	"flash-cards/backend/internal/ratelimit"
+	"flash-cards/backend/internal/repository"
+	"flash-cards/backend/internal/service"
+	"flash-cards/backend/internal/testkit"
+	"flash-cards/backend/internal/websocket"
+	"flash-cards/backend/pkg/client"
+)
+
+func TestCreateAndJoinSession(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	ownerConn := owner.Connect()
+	ownerConn.Expect(client.EventSnapshot)
+
+	guest := srv.Join(owner.Code, "  Bia   Souza ")
+	if guest.User.Name != "Bia Souza" || guest.User.Role != client.RoleGuest {
+		t.Fatalf("unexpected guest %+v", guest.User)
+	}
+	ownerConn.ExpectUser("join", "Bia Souza")
+
+	session := guest.Session()
+	if len(session.Users) != 2 || session.OwnerID != owner.User.ID || session.State != client.SessionOpen {
+		t.Fatalf("unexpected session %+v", session)
+	}
+
+	_, err := srv.API.JoinSession(context.Background(), owner.Code, "bia souza")
+	testkit.RequireCode(t, err, client.CodeNameTaken)
+
+	_, err = srv.API.JoinSession(context.Background(), "NOPE42", "Caio")
+	testkit.RequireCode(t, err, client.CodeSessionNotFound)
+}
+
+func TestVotingRound(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	bia := srv.Join(owner.Code, "Bia")
+	caio := srv.Join(owner.Code, "Caio")
+	conn := caio.Connect()
+	conn.Expect(client.EventSnapshot)
+
+	card := owner.CreateCard("Login")
+	conn.ExpectCard(func(c client.Card) bool { return c.ID == card.ID })
+
+	bia.Vote(card.ID, 3)
+	caio.Vote(card.ID, 8)
+	conn.ExpectCard(func(c client.Card) bool { return c.ID == card.ID && len(c.Votes) == 2 })
+
+	revealed := owner.Reveal(card.ID)
+	if !revealed.Closed || revealed.Result.Average != 5.5 {
+		t.Fatalf("unexpected result %+v", revealed)
+	}
+	if revealed.Result.Distribution[3] != 1 || revealed.Result.Distribution[8] != 1 {
+		t.Fatalf("unexpected distribution %v", revealed.Result.Distribution)
+	}
+	conn.ExpectCard(func(c client.Card) bool { return c.ID == card.ID && c.Closed })
+}
+
+func TestRevoteReplacesVote(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	bia := srv.Join(owner.Code, "Bia")
+	card := owner.CreateCard("Login")
+
+	bia.Vote(card.ID, 3)
+	bia.Vote(card.ID, 13)
+	voted := owner.Vote(card.ID, 5)
+	if len(voted.Votes) != 2 || voted.Result.Average != 9 || voted.Result.Distribution[3] != 0 {
+		t.Fatalf("each participant should count once, got %+v", voted)
+	}
+}
+
+func TestVoteOnRevealedCard(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	bia := srv.Join(owner.Code, "Bia")
+	card := owner.CreateCard("Login")
+	bia.Vote(card.ID, 3)
+	owner.Reveal(card.ID)
+
+	_, err := bia.Client.Vote(context.Background(), bia.Code, card.ID, 8)
+	testkit.RequireCode(t, err, client.CodeVotingClosed)
+	if cards := bia.Session().Cards; len(cards[0].Votes) != 1 || cards[0].Result.Average != 3 {
+		t.Fatalf("the rejected vote should not count, got %+v", cards[0])
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	guest := srv.Join(owner.Code, "Bia")
+	ctx := context.Background()
+
+	_, err := guest.Client.CreateCard(ctx, owner.Code, "Logout", "")
+	testkit.RequireCode(t, err, client.CodeNotOwner)
+
+	card := owner.CreateCard("Logout")
+	_, err = guest.Client.Reveal(ctx, owner.Code, card.ID)
+	testkit.RequireCode(t, err, client.CodeNotFacilitator)
+
+	if _, err := owner.SetRole(ctx, owner.Code, guest.User.ID, client.RoleFacilitator); err != nil {
+		t.Fatalf("promoting guest: %v", err)
+	}
+	// O token antigo ainda diz GUEST, mas o papel é conferido na sessão
+	if revealed := guest.Reveal(card.ID); !revealed.Closed {
+		t.Fatalf("card not revealed: %+v", revealed)
+	}
+}
+
+func TestKickRevokesTokenAndConnection(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	guest := srv.Join(owner.Code, "Bia")
+	guestConn := guest.Connect()
+	guestConn.Expect(client.EventSnapshot)
+	ownerConn := owner.Connect()
+	ownerConn.Expect(client.EventSnapshot)
+
+	if err := owner.KickUser(context.Background(), owner.Code, guest.User.ID); err != nil {
+		t.Fatalf("kicking guest: %v", err)
+	}
+	guestConn.ExpectClosed(client.CloseRevoked)
+	ownerConn.ExpectUser("kick", "Bia")
+
+	_, err := guest.GetSession(context.Background(), owner.Code)
+	testkit.RequireCode(t, err, client.CodeTokenRevoked)
+}
+
+func TestCloseSession(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	guest := srv.Join(owner.Code, "Bia")
+	conn := guest.Connect()
+	conn.Expect(client.EventSnapshot)
+
+	if err := owner.CloseSession(context.Background(), owner.Code); err != nil {
+		t.Fatalf("closing session: %v", err)
+	}
+	conn.ExpectClosed(client.CloseSessionClosed)
+
+	_, err := srv.API.JoinSession(context.Background(), owner.Code, "Caio")
+	testkit.RequireCode(t, err, client.CodeSessionClosed)
+}
+
+func TestValidation(t *testing.T) {
+	srv := testkit.NewServer(t)
+	ctx := context.Background()
+
+	_, err := srv.API.CreateSession(ctx, strings.Repeat("a", 41), "")
+	apiErr := testkit.RequireCode(t, err, client.CodeInvalidRequest)
+	if apiErr.Status != http.StatusBadRequest || len(apiErr.Fields) == 0 || apiErr.Fields[0].Field != "ownerName" {
+		t.Fatalf("unexpected error %+v", apiErr)
+	}
+
+	owner := srv.CreateSession("Ana")
+	_, err = owner.Client.CreateCard(ctx, owner.Code, "   ", "")
+	testkit.RequireCode(t, err, client.CodeInvalidRequest)
+
+	for _, path := range []string{"/api/v1/sessions/", "/sessions/"} {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+path+owner.Code+"/state", strings.NewReader(`{"state":"PAUSED"}`))
+		req.Header.Set("Authorization", "Bearer "+owner.Token())
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: unknown state answered %d", path, resp.StatusCode)
+		}
+	}
+	if state := owner.Session().State; state != client.SessionOpen {
+		t.Fatalf("unknown state was stored: %s", state)
+	}
+
+	_, err = srv.API.WithLanguage("pt-BR").JoinSession(ctx, "NOPE42", "Bia")
+	if apiErr := testkit.RequireCode(t, err, client.CodeSessionNotFound); apiErr.Message != "sessão não encontrada" {
+		t.Fatalf("expected a Portuguese message, got %q", apiErr.Message)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	sessionPath := "/api/v1/sessions/" + owner.Code
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"invalid field", http.MethodPut, sessionPath + "/state", `{"state":"PAUSED"}`, http.StatusBadRequest, client.CodeInvalidRequest},
+		{"body too large", http.MethodPost, sessionPath + "/cards", `{"title":"` + strings.Repeat("a", 32<<10) + `"}`, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"},
+		{"unknown route", http.MethodGet, sessionPath + "/unknown", "", http.StatusNotFound, "ROUTE_NOT_FOUND"},
+		{"unknown legacy route", http.MethodGet, "/unknown", "", http.StatusNotFound, "ROUTE_NOT_FOUND"},
+		{"method not allowed", http.MethodDelete, sessionPath + "/state", "", http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"method not allowed on the legacy route", http.MethodPatch, "/sessions/" + owner.Code, "", http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, srv.URL+test.path, strings.NewReader(test.body))
+			req.Header.Set("Authorization", "Bearer "+owner.Token())
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Code string `json:"code"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding the error: %v", err)
+			}
+			if resp.StatusCode != test.status || body.Code != test.code {
+				t.Fatalf("%s %s answered %d %s, want %d %s", test.method, test.path, resp.StatusCode, body.Code, test.status, test.code)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	stale := owner.Session().Version
+
+	owner.CreateCard("Login")
+	_, err := owner.Client.CreateCard(client.IfMatch(context.Background(), stale), owner.Code, "Logout", "")
+	testkit.RequireCode(t, err, client.CodeVersionMismatch)
+
+	current := owner.Session().Version
+	if _, err := owner.Client.CreateCard(client.IfMatch(context.Background(), current), owner.Code, "Logout", ""); err != nil {
+		t.Fatalf("creating card with the current version: %v", err)
+	}
+}
+
+func TestVotesKeepTheETag(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	card := owner.CreateCard("Login")
+	bia := srv.Join(owner.Code, "Bia")
+	version := owner.Session().Version
+
+	// Votos de outros participantes não invalidam o If-Match do owner
+	for score := 1; score <= 3; score++ {
+		bia.Vote(card.ID, score)
+		owner.Vote(card.ID, score)
+	}
+	if got := owner.Session().Version; got != version {
+		t.Fatalf("votes moved the version from %d to %d", version, got)
+	}
+	ctx := client.IfMatch(context.Background(), version)
+	if err := owner.Client.SetSessionState(ctx, owner.Code, client.SessionClosed); err != nil {
+		t.Fatalf("closing with the version read before the votes: %v", err)
+	}
+
+	// O voto continua respeitando o If-Match que recebe
+	_, err := bia.Client.Vote(client.IfMatch(context.Background(), version), owner.Code, card.ID, 5)
+	testkit.RequireCode(t, err, client.CodeVersionMismatch)
+}
+
+func TestResumeFromLastSeq(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	guest := srv.Join(owner.Code, "Bia")
+
+	conn := guest.Connect()
+	conn.Expect(client.EventSnapshot)
+	first := owner.CreateCard("Login")
+	conn.ExpectCard(func(c client.Card) bool { return c.ID == first.ID })
+	lastSeq := conn.LastSeq()
+	conn.Close()
+
+	missed := owner.CreateCard("Logout")
+
+	resumed := guest.Connect(client.SubscribeOptions{LastSeq: lastSeq})
+	event := resumed.Next()
+	if event.Type != client.EventCardUpdate || event.Seq != lastSeq+1 {
+		t.Fatalf("expected the missed card_update #%d, got #%d %s", lastSeq+1, event.Seq, event.Type)
+	}
+	if card, err := event.Card(); err != nil || card.ID != missed.ID {
+		t.Fatalf("expected card %s, got %+v (%v)", missed.ID, card, err)
+	}
+}
+
+func TestResumeAfterIdleHubIsReaped(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	srv := testkit.NewServer(t, testkit.WithClock(fake), testkit.WithConfig(func(cfg *config.Config) {
+		cfg.Websocket.IdleTimeout = config.Duration(time.Minute)
+		cfg.Websocket.BatchWindow = 0
+	}))
+	owner := srv.CreateSession("Ana")
+	guest := srv.Join(owner.Code, "Bia")
+
+	conn := guest.Connect()
+	conn.Expect(client.EventSnapshot)
+	for _, title := range []string{"Login", "Logout", "Signup"} {
+		card := owner.CreateCard(title)
+		conn.ExpectCard(func(c client.Card) bool { return c.ID == card.ID })
+	}
+	lastSeq, stream := conn.LastSeq(), conn.Stream()
+	conn.Close()
+	waitForHubs(t, srv, fake, 0)
+
+	// O novo hub recomeça do seq zero: o cliente à frente dele recebe um snapshot
+	missed := owner.CreateCard("Profile")
+	resumed := guest.Connect(client.SubscribeOptions{LastSeq: lastSeq})
+	event := resumed.Expect(client.EventSnapshot)
+	snapshot, err := event.Snapshot()
+	if err != nil || len(snapshot.Session.Cards) != 4 || snapshot.Stream == stream {
+		t.Fatalf("expected a snapshot of a new stream with 4 cards, got %+v (%v)", snapshot, err)
+	}
+	if resumed.LastSeq() != event.Seq {
+		t.Fatalf("the client should restart from the snapshot seq %d, got %d", event.Seq, resumed.LastSeq())
+	}
+	if !containsCard(snapshot.Session.Cards, missed.ID) {
+		t.Fatalf("snapshot is missing card %s", missed.ID)
+	}
+
+	// Mesmo com o novo hub à frente do lastSeq antigo, o stream antigo força um snapshot
+	for _, title := range []string{"A", "B", "C", "D", "E"} {
+		card := owner.CreateCard(title)
+		resumed.ExpectCard(func(c client.Card) bool { return c.ID == card.ID })
+	}
+	again := guest.Connect(client.SubscribeOptions{LastSeq: lastSeq, Stream: stream})
+	if event := again.Next(); event.Type != client.EventSnapshot {
+		t.Fatalf("expected a snapshot for a stale stream, got #%d %s", event.Seq, event.Type)
+	}
+}
+
+// waitForHubs avança o relógio falso até o servidor ter n hubs ativos
+func waitForHubs(t *testing.T, srv *testkit.Server, fake *clock.Fake, n int) {
+	t.Helper()
+
+	want := fmt.Sprintf("poker_hubs %d\n", n)
+	deadline := time.Now().Add(testkit.Timeout)
+	for time.Now().Before(deadline) {
+		fake.Advance(15 * time.Second)
+		resp, err := srv.Client().Get(srv.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(body), want) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server did not reach %d hubs", n)
+}
+
+func containsCard(cards []client.Card, id string) bool {
+	for _, card := range cards {
+		if card.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPatchStreamDoesNotRepeatCards(t *testing.T) {
+	srv := testkit.NewServer(t, testkit.WithConfig(func(cfg *config.Config) {
+		cfg.Websocket.BatchWindow = 0
+	}))
+	owner := srv.CreateSession("Ana")
+	conn := owner.Connect()
+
+	// O cliente de patches aplica card_update ao documento e session_patch por cima
+	var snapshot struct {
+		Session interface{} `json:"session"`
+		Version uint64      `json:"version"`
+	}
+	if err := json.Unmarshal(conn.Expect(client.EventSnapshot).Data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	document, version := snapshot.Session, snapshot.Version
+
+	card := owner.CreateCard("Login")
+	update := conn.Expect(client.EventCardUpdate)
+	var item interface{}
+	if err := json.Unmarshal(update.Data, &item); err != nil {
+		t.Fatal(err)
+	}
+	cards := document.(map[string]interface{})["cards"].([]interface{})
+	document.(map[string]interface{})["cards"] = append(cards, item)
+
+	srv.Join(owner.Code, "Bia")
+	if err := owner.CloseSession(context.Background(), owner.Code); err != nil {
+		t.Fatal(err)
+	}
+	// Um patch para a entrada de Bia e outro para o fechamento
+	for i := 0; i < 2; i++ {
+		var patch websocket.SessionPatch
+		if err := json.Unmarshal(conn.Expect(client.EventSessionPatch).Data, &patch); err != nil {
+			t.Fatal(err)
+		}
+		if patch.BaseVersion != version {
+			t.Fatalf("patch based on version %d, client has %d", patch.BaseVersion, version)
+		}
+		for _, op := range patch.Ops {
+			if strings.HasPrefix(op.Path, "/cards") {
+				t.Fatalf("card change repeated in session_patch: %+v", op)
+			}
+		}
+
+		var err error
+		if document, err = jsonpatch.Apply(document, patch.Ops); err != nil {
+			t.Fatal(err)
+		}
+		version = patch.Version
+	}
+	raw, _ := json.Marshal(document)
+	var session client.Session
+	if err := json.Unmarshal(raw, &session); err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Cards) != 1 || session.Cards[0].ID != card.ID || len(session.Users) != 2 || session.State != client.SessionClosed {
+		t.Fatalf("unexpected document after the patch: %+v", session)
+	}
+}
+
+func TestParticipantChangesReachTheDocument(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	patches := owner.Connect()
+	patches.Expect(client.EventSnapshot)
+	full := owner.Connect(client.SubscribeOptions{Full: true})
+	full.Expect(client.EventSnapshot)
+
+	guest := srv.Join(owner.Code, "Bia")
+
+	patches.ExpectUser("join", "Bia")
+	patch, err := patches.Expect(client.EventSessionPatch).Patch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usersChanged bool
+	for _, op := range patch.Ops {
+		usersChanged = usersChanged || strings.HasPrefix(op.Path, "/users")
+	}
+	if !usersChanged {
+		t.Fatalf("join did not patch /users: %+v", patch.Ops)
+	}
+
+	full.ExpectUser("join", "Bia")
+	session, err := full.Expect(client.EventSessionUpdate).Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Users) != 2 || session.Users[1].ID != guest.User.ID {
+		t.Fatalf("session_update without the new participant: %+v", session.Users)
+	}
+
+	// Mudança de papel e expulsão também chegam ao documento
+	if _, err := owner.SetRole(context.Background(), owner.Code, guest.User.ID, client.RoleFacilitator); err != nil {
+		t.Fatal(err)
+	}
+	full.ExpectUser("role", "Bia")
+	if session, _ = full.Expect(client.EventSessionUpdate).Session(); session.Users[1].Role != client.RoleFacilitator {
+		t.Fatalf("session_update without the new role: %+v", session.Users)
+	}
+	if err := owner.KickUser(context.Background(), owner.Code, guest.User.ID); err != nil {
+		t.Fatal(err)
+	}
+	full.ExpectUser("kick", "Bia")
+	if session, _ = full.Expect(client.EventSessionUpdate).Session(); len(session.Users) != 1 {
+		t.Fatalf("session_update still lists the kicked participant: %+v", session.Users)
+	}
+}
+
+func TestShutdownNotifiesClients(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	conn := owner.Connect()
+	conn.Expect(client.EventSnapshot)
+
+	if err := srv.App.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutting down: %v", err)
+	}
+	event := conn.Expect(client.EventServerRestarting)
+	notice, err := event.ServerRestarting()
+	if err != nil || notice.ReconnectAfterMs <= 0 {
+		t.Fatalf("unexpected notice %+v: %v", notice, err)
+	}
+
+	_, err = srv.API.CreateSession(context.Background(), "Bia", "")
+	testkit.RequireCode(t, err, client.CodeShuttingDown)
+}
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/sessions/"+owner.Code, nil)
+	req.Header.Set("Authorization", "Bearer "+owner.Token())
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Deprecation") != "true" {
+		t.Fatalf("unexpected legacy response: %d, Deprecation %q", resp.StatusCode, resp.Header.Get("Deprecation"))
+	}
+	if link := resp.Header.Get("Link"); !strings.Contains(link, "/api/v1/sessions/"+owner.Code) {
+		t.Fatalf("unexpected Link %q", link)
+	}
+}
+
+// downCards é um CardStore cujo armazenamento está fora do ar
+type downCards struct {
+	service.CardStore
+}
+
+func (downCards) Ping() error { return errors.New("connection refused") }
+
+func TestReadinessPingsCustomStores(t *testing.T) {
+	srv := testkit.NewServer(t, func(opts *app.Options) {
+		opts.Cards = downCards{repository.NewCardRepository(random.NewIDGenerator(random.NewSeeded(1)))}
+	})
+
+	resp, err := srv.Client().Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz returned %d with the card store down", resp.StatusCode)
+	}
+}
+
+func TestTokenInQueryOnlyOnWebsocket(t *testing.T) {
+	srv := testkit.NewServer(t, testkit.WithConfig(func(cfg *config.Config) {
+		cfg.Admin.Token = "debug-admin-token-0123"
+	}))
+	owner := srv.CreateSession("Ana")
+
+	tests := []struct {
+		path  string
+		token string
+	}{
+		{"/api/v1/sessions/" + owner.Code, owner.Token()},
+		{"/sessions/" + owner.Code + "/cards", owner.Token()},
+		{"/api/v1/cards", owner.Token()},
+		{"/debug/hubs", "debug-admin-token-0123"},
+	}
+	for _, test := range tests {
+		resp, err := srv.Client().Get(srv.URL + test.path + "?token=" + test.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s accepted a token in the query: %d", test.path, resp.StatusCode)
+		}
+	}
+
+	// O upgrade do WebSocket continua aceitando ?token=
+	owner.Connect().Expect(client.EventSnapshot)
+}
+
+func TestDebugRequiresAdminToken(t *testing.T) {
+	const adminToken = "debug-admin-token-0123"
+
+	tests := []struct {
+		name       string
+		configured string
+		token      string
+		status     int
+	}{
+		{"disabled without a configured token", "", adminToken, http.StatusNotFound},
+		{"missing token", adminToken, "", http.StatusUnauthorized},
+		{"wrong token", adminToken, "not-the-admin-token", http.StatusUnauthorized},
+		{"participant token", adminToken, "participant", http.StatusUnauthorized},
+		{"admin token", adminToken, adminToken, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := testkit.NewServer(t, testkit.WithConfig(func(cfg *config.Config) {
+				cfg.Admin.Token = test.configured
+			}))
+			token := test.token
+			if token == "participant" {
+				token = srv.CreateSession("Ana").Token()
+			}
+
+			for _, path := range []string{"/debug/hubs", "/debug/build", "/debug/pprof/"} {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				resp, err := srv.Client().Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != test.status {
+					t.Fatalf("%s returned %d, want %d", path, resp.StatusCode, test.status)
+				}
+				if test.status == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+					t.Fatalf("%s returned 401 without WWW-Authenticate", path)
+				}
+			}
+		})
+	}
+}
+
+func TestDebugBuildUsesClock(t *testing.T) {
+	const adminToken = "debug-admin-token-0123"
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	srv := testkit.NewServer(t, testkit.WithClock(fake), testkit.WithConfig(func(cfg *config.Config) {
+		cfg.Admin.Token = adminToken
+	}))
+	fake.Advance(90 * time.Minute)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/debug/build", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var build struct {
+		StartedAt time.Time `json:"startedAt"`
+		Uptime    string    `json:"uptime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&build); err != nil {
+		t.Fatal(err)
+	}
+	if !build.StartedAt.Equal(start) || build.Uptime != "1h30m0s" {
+		t.Fatalf("started at %s with uptime %s, want %s and 1h30m0s", build.StartedAt, build.Uptime, start)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	srv := testkit.NewServer(t)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s returned %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	srv := testkit.NewServer(t, testkit.WithClock(fake))
+
+	owner := srv.CreateSession("Ana")
+	fake.Advance(time.Hour)
+	guest := srv.Join(owner.Code, "Bia")
+
+	session := owner.Session()
+	if !session.CreatedAt.Equal(start) || !session.Users[0].JoinedAt.Equal(start) {
+		t.Fatalf("session should be created at %s, got %+v", start, session)
+	}
+	if !guest.User.JoinedAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("guest should join at %s, got %s", start.Add(time.Hour), guest.User.JoinedAt)
+	}
+
+	// O token do owner vence 12h depois de criado; o do convidado, uma hora depois
+	fake.Advance(time.Duration(srv.Config.Token.TTL) - time.Hour + time.Second)
+	_, err := owner.GetSession(context.Background(), owner.Code)
+	testkit.RequireCode(t, err, client.CodeTokenExpired)
+	if _, err := guest.GetSession(context.Background(), guest.Code); err != nil {
+		t.Fatalf("guest token should still be valid: %v", err)
+	}
+}
+
+func TestSeedIsReproducible(t *testing.T) {
+	run := func() (client.Session, client.Card) {
+		srv := testkit.NewServer(t, testkit.WithSeed(42))
+		owner := srv.CreateSession("Ana")
+		srv.Join(owner.Code, "Bia")
+		card := owner.CreateCard("Login")
+		return owner.Session(), card
+	}
+
+	first, firstCard := run()
+	second, secondCard := run()
+	if first.Code != second.Code || first.ID != second.ID || firstCard.ID != secondCard.ID {
+		t.Fatalf("same seed produced different values: %s/%s/%s and %s/%s/%s",
+			first.Code, first.ID, firstCard.ID, second.Code, second.ID, secondCard.ID)
+	}
+	for i := range first.Users {
+		if first.Users[i].ID != second.Users[i].ID {
+			t.Fatalf("user %d: %s != %s", i, first.Users[i].ID, second.Users[i].ID)
+		}
+	}
+}
+
+func TestMetricsDoNotExposeSessionCodes(t *testing.T) {
+	srv := testkit.NewServer(t)
+	owner := srv.CreateSession("Ana")
+	conn := owner.Connect()
+	conn.Expect(client.EventSnapshot)
+
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(body), owner.Code) {
+		t.Fatalf("/metrics exposes session code %s", owner.Code)
+	}
+	for _, line := range []string{"poker_ws_clients 1", `poker_hub_clients_bucket{le="1"} 1`, "poker_hub_clients_count 1"} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Fatalf("/metrics is missing %q", line)
+		}
+	}
+}
+
+func TestSessionRateLimitIgnoresOutsiders(t *testing.T) {
+	srv := testkit.NewServer(t, testkit.WithConfig(func(cfg *config.Config) {
+		cfg.RateLimits.PerSession = ratelimit.Per(1, time.Hour, 2)
+	}))
+	owner := srv.CreateSession("Ana")
+
+	// Sem token da sessão, as requisições não gastam o bucket dela
+	for i := 0; i < 5; i++ {
+		_, err := srv.API.GetSession(context.Background(), owner.Code)
+		testkit.RequireCode(t, err, "TOKEN_REQUIRED")
+	}
+
+	owner.Session()
+	owner.Session()
+	_, err := owner.GetSession(context.Background(), owner.Code)
+	if apiErr := testkit.RequireCode(t, err, client.CodeRateLimited); apiErr.RetryAfter != 3600 {
+		t.Fatalf("expected Retry-After 3600, got %d", apiErr.RetryAfter)
+	}
+}
+
+func TestFailedLookupsCountOnlyUnknownSessions(t *testing.T) {
+	srv := testkit.NewServer(t, testkit.WithConfig(func(cfg *config.Config) {
+		cfg.RateLimits.FailedLookups = ratelimit.Per(1, time.Hour, 2)
+	}))
+	owner := srv.CreateSession("Ana")
+
+	for i := 0; i < 5; i++ {
+		_, err := owner.Client.Vote(context.Background(), owner.Code, "missing-card", 3)
+		testkit.RequireCode(t, err, client.CodeCardNotFound)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := srv.API.JoinSession(context.Background(), "NOPE42", "Bia")
+		testkit.RequireCode(t, err, client.CodeSessionNotFound)
+	}
+	_, err := srv.API.JoinSession(context.Background(), "NOPE42", "Bia")
+	testkit.RequireCode(t, err, client.CodeRateLimited)
+}
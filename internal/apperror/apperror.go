@@ -0,0 +1,136 @@
+// Package apperror define os erros da API: um código estável para clientes
+// (SESSION_NOT_FOUND, NOT_OWNER...), o status HTTP correspondente e a mensagem
+// em cada idioma suportado, tudo na mesma tabela.
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"flash-cards/backend/internal/logging"
+)
+
+// Code identifica um erro de forma estável; clientes devem decidir pelo código,
+// nunca pela mensagem
+type Code string
+
+const (
+	CodeInvalidRequest     Code = "INVALID_REQUEST"
+	CodeInvalidVote        Code = "INVALID_VOTE"
+	CodeInvalidRole        Code = "INVALID_ROLE"
+	CodeInvalidSessionCode Code = "INVALID_SESSION_CODE"
+	CodeVanityDisabled     Code = "VANITY_CODES_DISABLED"
+	CodeSessionCodeTaken   Code = "SESSION_CODE_TAKEN"
+	CodeSessionCodeFull    Code = "SESSION_CODES_EXHAUSTED"
+	CodePayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"
+	CodeNameTaken          Code = "NAME_TAKEN"
+
+	CodeTokenRequired      Code = "TOKEN_REQUIRED"
+	CodeInvalidToken       Code = "INVALID_TOKEN"
+	CodeTokenExpired       Code = "TOKEN_EXPIRED"
+	CodeTokenRevoked       Code = "TOKEN_REVOKED"
+	CodeTokenWrongSession  Code = "TOKEN_WRONG_SESSION"
+	CodeAdminTokenRequired Code = "ADMIN_TOKEN_REQUIRED"
+
+	CodeNotOwner       Code = "NOT_OWNER"
+	CodeNotFacilitator Code = "NOT_FACILITATOR"
+	CodeNotMember      Code = "NOT_MEMBER"
+	CodeSessionClosed  Code = "SESSION_CLOSED"
+	CodeVotingClosed   Code = "VOTING_CLOSED"
+
+	CodeSessionNotFound Code = "SESSION_NOT_FOUND"
+	CodeCardNotFound    Code = "CARD_NOT_FOUND"
+	CodeUserNotFound    Code = "USER_NOT_FOUND"
+	CodeRouteNotFound   Code = "ROUTE_NOT_FOUND"
+
+	CodeMethodNotAllowed Code = "METHOD_NOT_ALLOWED"
+	CodeVersionMismatch  Code = "VERSION_MISMATCH"
+	CodeRateLimited      Code = "RATE_LIMITED"
+	CodeShuttingDown     Code = "SERVER_RESTARTING"
+	CodeInternal         Code = "INTERNAL_ERROR"
+)
+
+// Field detalha um campo inválido da requisição
+type Field struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error é um erro da API. Dois erros com o mesmo código são equivalentes para
+// errors.Is, mesmo que um deles traga detalhes de campos.
+type Error struct {
+	Code   Code
+	Fields []Field
+}
+
+// New cria um erro com o código informado
+func New(code Code) *Error {
+	return &Error{Code: code}
+}
+
+// WithFields retorna uma cópia do erro com os detalhes dos campos
+func (e *Error) WithFields(fields ...Field) *Error {
+	return &Error{Code: e.Code, Fields: fields}
+}
+
+// Error retorna a mensagem em português, usada nos logs
+func (e *Error) Error() string {
+	return e.Message(Portuguese)
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Status retorna o status HTTP do erro
+func (e *Error) Status() int {
+	if entry, ok := catalog[e.Code]; ok {
+		return entry.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message retorna a mensagem do erro no idioma informado
+func (e *Error) Message(language Language) string {
+	entry, ok := catalog[e.Code]
+	if !ok {
+		entry = catalog[CodeInternal]
+	}
+	if message, ok := entry.messages[language]; ok {
+		return message
+	}
+	return entry.messages[DefaultLanguage]
+}
+
+// Response é o corpo JSON das respostas de erro. Error traz a mensagem
+// localizada, mantendo o campo usado pelos clientes anteriores aos códigos.
+type Response struct {
+	Error  string  `json:"error"`
+	Code   Code    `json:"code"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Write responde com o erro no idioma pedido em Accept-Language. Erros que não
+// são *Error viram INTERNAL_ERROR; o original só aparece no log.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		logging.FromContext(r.Context()).Error("erro interno", "error", err)
+		appErr = New(CodeInternal)
+	}
+
+	language := Negotiate(r.Header.Get("Accept-Language"))
+	body, _ := json.Marshal(Response{
+		Error:  appErr.Message(language),
+		Code:   appErr.Code,
+		Fields: appErr.Fields,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", string(language))
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(appErr.Status())
+	w.Write(body)
+}
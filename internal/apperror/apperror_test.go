@@ -0,0 +1,164 @@
+package apperror
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flash-cards/backend/internal/logging"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Language
+	}{
+		{"", DefaultLanguage},
+		{"pt-BR", Portuguese},
+		{"pt", Portuguese},
+		{"pt-PT", Portuguese},
+		{"PT-br", Portuguese},
+		{"en-US", English},
+		{"fr-FR", DefaultLanguage},
+		{"fr, de;q=0.9", DefaultLanguage},
+		{"*", DefaultLanguage},
+		{"fr, pt;q=0.5", Portuguese},
+		{"en;q=0.5, pt-BR;q=0.8", Portuguese},
+		{"pt-BR;q=0.8, en", English},
+		{"pt-BR,pt;q=0.9,en;q=0.8", Portuguese},
+		// Empate fica com o primeiro idioma do header
+		{"en;q=0.7, pt;q=0.7", English},
+		{"pt;q=0.7, en;q=0.7", Portuguese},
+		// q=0 recusa o idioma
+		{"pt;q=0", DefaultLanguage},
+		// Qualidade inválida descarta só aquela entrada
+		{"pt;q=abc, en;q=0.1", English},
+		{"pt;q=abc", DefaultLanguage},
+		{" pt-BR ; q=0.9 ", Portuguese},
+	}
+	for _, test := range tests {
+		if got := Negotiate(test.header); got != test.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", test.header, got, test.want)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	statuses := map[Code]int{
+		CodeInvalidRequest:     http.StatusBadRequest,
+		CodeInvalidVote:        http.StatusBadRequest,
+		CodeInvalidRole:        http.StatusBadRequest,
+		CodeInvalidSessionCode: http.StatusBadRequest,
+		CodeVanityDisabled:     http.StatusForbidden,
+		CodeSessionCodeTaken:   http.StatusConflict,
+		CodeSessionCodeFull:    http.StatusServiceUnavailable,
+		CodePayloadTooLarge:    http.StatusRequestEntityTooLarge,
+		CodeNameTaken:          http.StatusConflict,
+		CodeTokenRequired:      http.StatusUnauthorized,
+		CodeInvalidToken:       http.StatusUnauthorized,
+		CodeTokenExpired:       http.StatusUnauthorized,
+		CodeTokenRevoked:       http.StatusUnauthorized,
+		CodeTokenWrongSession:  http.StatusUnauthorized,
+		CodeAdminTokenRequired: http.StatusUnauthorized,
+		CodeNotOwner:           http.StatusForbidden,
+		CodeNotFacilitator:     http.StatusForbidden,
+		CodeNotMember:          http.StatusForbidden,
+		CodeSessionClosed:      http.StatusForbidden,
+		CodeVotingClosed:       http.StatusConflict,
+		CodeSessionNotFound:    http.StatusNotFound,
+		CodeCardNotFound:       http.StatusNotFound,
+		CodeUserNotFound:       http.StatusNotFound,
+		CodeRouteNotFound:      http.StatusNotFound,
+		CodeMethodNotAllowed:   http.StatusMethodNotAllowed,
+		CodeVersionMismatch:    http.StatusPreconditionFailed,
+		CodeRateLimited:        http.StatusTooManyRequests,
+		CodeShuttingDown:       http.StatusServiceUnavailable,
+		CodeInternal:           http.StatusInternalServerError,
+	}
+
+	codes := Codes()
+	if len(codes) != len(statuses) {
+		t.Fatalf("the catalog has %d codes, the test expects %d", len(codes), len(statuses))
+	}
+	for _, code := range codes {
+		want, ok := statuses[code]
+		if !ok {
+			t.Errorf("%s has no expected status in the test", code)
+			continue
+		}
+		err := New(code)
+		if got := err.Status(); got != want {
+			t.Errorf("%s: status %d, want %d", code, got, want)
+		}
+		for _, language := range []Language{English, Portuguese} {
+			if message, ok := catalog[code].messages[language]; !ok || message == "" {
+				t.Errorf("%s has no %s message", code, language)
+			}
+		}
+	}
+}
+
+func TestUnknownCode(t *testing.T) {
+	err := New("NOT_IN_THE_CATALOG")
+	if got := err.Status(); got != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", got)
+	}
+	if got, want := err.Message(English), New(CodeInternal).Message(English); got != want {
+		t.Fatalf("message %q, want %q", got, want)
+	}
+	if got, want := New(CodeNotOwner).Message("fr"), New(CodeNotOwner).Message(DefaultLanguage); got != want {
+		t.Fatalf("unsupported language returned %q, want %q", got, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		language string
+		status   int
+		code     Code
+		message  string
+	}{
+		{"catalog error", New(CodeSessionNotFound), "", http.StatusNotFound, CodeSessionNotFound, "Session not found"},
+		{"Portuguese", New(CodeSessionNotFound), "pt-BR", http.StatusNotFound, CodeSessionNotFound, "sessão não encontrada"},
+		{"wrapped error", fmt.Errorf("lookup: %w", New(CodeNotOwner)), "en", http.StatusForbidden, CodeNotOwner, New(CodeNotOwner).Message(English)},
+		{"plain error", errors.New("disk on fire"), "", http.StatusInternalServerError, CodeInternal, "Internal server error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(logging.NewContext(r.Context(), slog.New(slog.NewTextHandler(&logs, nil))))
+			if test.language != "" {
+				r.Header.Set("Accept-Language", test.language)
+			}
+			recorder := httptest.NewRecorder()
+			Write(recorder, r, test.err)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d", recorder.Code, test.status)
+			}
+			var body Response
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != test.code || body.Error != test.message {
+				t.Fatalf("body %+v, want %s %q", body, test.code, test.message)
+			}
+			if got := recorder.Header().Get("Content-Language"); got != string(Negotiate(test.language)) {
+				t.Fatalf("Content-Language %q", got)
+			}
+			// O erro original só aparece no log, nunca na resposta
+			if logged := strings.Contains(logs.String(), "disk on fire"); logged != (test.code == CodeInternal) {
+				t.Fatalf("log %q", logs.String())
+			}
+		})
+	}
+}
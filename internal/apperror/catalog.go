@@ -0,0 +1,192 @@
+package apperror
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language é um idioma suportado nas mensagens de erro
+type Language string
+
+const (
+	English    Language = "en"
+	Portuguese Language = "pt-BR"
+
+	// DefaultLanguage é usado quando Accept-Language não pede um idioma suportado
+	DefaultLanguage = English
+)
+
+type entry struct {
+	status   int
+	messages map[Language]string
+}
+
+// catalog é o único lugar que associa códigos a status HTTP e mensagens
+var catalog = map[Code]entry{
+	CodeInvalidRequest: {http.StatusBadRequest, map[Language]string{
+		English:    "Invalid request payload",
+		Portuguese: "requisição inválida",
+	}},
+	CodeInvalidVote: {http.StatusBadRequest, map[Language]string{
+		English:    "Invalid vote",
+		Portuguese: "voto inválido",
+	}},
+	CodeInvalidRole: {http.StatusBadRequest, map[Language]string{
+		English:    "Invalid role: use FACILITATOR or GUEST",
+		Portuguese: "papel inválido: use FACILITATOR ou GUEST",
+	}},
+	CodeInvalidSessionCode: {http.StatusBadRequest, map[Language]string{
+		English:    "Invalid session code: use 4 to 32 letters, digits or hyphens",
+		Portuguese: "código de sessão inválido: use de 4 a 32 letras, números ou hífens",
+	}},
+	CodeVanityDisabled: {http.StatusForbidden, map[Language]string{
+		English:    "Custom session codes are not enabled",
+		Portuguese: "códigos personalizados não estão habilitados",
+	}},
+	CodeSessionCodeTaken: {http.StatusConflict, map[Language]string{
+		English:    "Session code is already in use",
+		Portuguese: "código de sessão já está em uso",
+	}},
+	CodeSessionCodeFull: {http.StatusServiceUnavailable, map[Language]string{
+		English:    "Could not generate a free session code",
+		Portuguese: "não foi possível gerar um código de sessão livre",
+	}},
+	CodePayloadTooLarge: {http.StatusRequestEntityTooLarge, map[Language]string{
+		English:    "Request body is too large",
+		Portuguese: "corpo da requisição é grande demais",
+	}},
+	CodeNameTaken: {http.StatusConflict, map[Language]string{
+		English:    "Another participant in this session already uses this name",
+		Portuguese: "outro participante da sessão já usa esse nome",
+	}},
+
+	CodeTokenRequired: {http.StatusUnauthorized, map[Language]string{
+		English:    "Token is required",
+		Portuguese: "token é obrigatório",
+	}},
+	CodeInvalidToken: {http.StatusUnauthorized, map[Language]string{
+		English:    "Invalid token",
+		Portuguese: "token inválido",
+	}},
+	CodeTokenExpired: {http.StatusUnauthorized, map[Language]string{
+		English:    "Token has expired",
+		Portuguese: "token expirado",
+	}},
+	CodeTokenRevoked: {http.StatusUnauthorized, map[Language]string{
+		English:    "Token has been revoked",
+		Portuguese: "token revogado",
+	}},
+	CodeTokenWrongSession: {http.StatusUnauthorized, map[Language]string{
+		English:    "Token does not belong to this session",
+		Portuguese: "token não pertence a esta sessão",
+	}},
+	CodeAdminTokenRequired: {http.StatusUnauthorized, map[Language]string{
+		English:    "Admin token is required",
+		Portuguese: "token de administrador é obrigatório",
+	}},
+
+	CodeNotOwner: {http.StatusForbidden, map[Language]string{
+		English:    "Only the session owner can do this",
+		Portuguese: "apenas o dono da sessão pode fazer isso",
+	}},
+	CodeNotFacilitator: {http.StatusForbidden, map[Language]string{
+		English:    "Only the owner or a facilitator can do this",
+		Portuguese: "apenas o dono ou um facilitador pode fazer isso",
+	}},
+	CodeNotMember: {http.StatusForbidden, map[Language]string{
+		English:    "User is not a member of the session",
+		Portuguese: "usuário não participa da sessão",
+	}},
+	CodeSessionClosed: {http.StatusForbidden, map[Language]string{
+		English:    "Session is closed",
+		Portuguese: "sessão está fechada",
+	}},
+	CodeVotingClosed: {http.StatusConflict, map[Language]string{
+		English:    "Voting on this card is closed",
+		Portuguese: "a votação deste card está encerrada",
+	}},
+
+	CodeSessionNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "Session not found",
+		Portuguese: "sessão não encontrada",
+	}},
+	CodeCardNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "Card not found in the session",
+		Portuguese: "card não encontrado na sessão",
+	}},
+	CodeUserNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "User not found in the session",
+		Portuguese: "usuário não encontrado na sessão",
+	}},
+	CodeRouteNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "Route not found",
+		Portuguese: "rota não encontrada",
+	}},
+
+	CodeMethodNotAllowed: {http.StatusMethodNotAllowed, map[Language]string{
+		English:    "Method not allowed on this route",
+		Portuguese: "método não permitido nesta rota",
+	}},
+	CodeVersionMismatch: {http.StatusPreconditionFailed, map[Language]string{
+		English:    "The session was changed by another request",
+		Portuguese: "a sessão foi alterada por outra requisição",
+	}},
+	CodeRateLimited: {http.StatusTooManyRequests, map[Language]string{
+		English:    "Too many requests",
+		Portuguese: "muitas requisições",
+	}},
+	CodeShuttingDown: {http.StatusServiceUnavailable, map[Language]string{
+		English:    "Server is restarting",
+		Portuguese: "servidor está sendo reiniciado",
+	}},
+	CodeInternal: {http.StatusInternalServerError, map[Language]string{
+		English:    "Internal server error",
+		Portuguese: "erro interno do servidor",
+	}},
+}
+
+// Codes retorna todos os códigos conhecidos, em ordem alfabética
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// Negotiate escolhe o idioma a partir de um header Accept-Language, como
+// "pt-BR,pt;q=0.9,en;q=0.8". Qualquer variante de português usa pt-BR.
+func Negotiate(header string) Language {
+	best, bestQuality := DefaultLanguage, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		language, ok := supported(tag)
+		if ok && quality > bestQuality {
+			best, bestQuality = language, quality
+		}
+	}
+	return best
+}
+
+func supported(tag string) (Language, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case "pt":
+		return Portuguese, true
+	case "en":
+		return English, true
+	}
+	return "", false
+}
@@ -0,0 +1,42 @@
+// Package clock abstrai a hora atual e os temporizadores, para que o servidor
+// possa ser montado em testes com um relógio controlado (Fake).
+//
+// Passam pelo Clock as datas gravadas (criação de sessões, entrada de
+// participantes), a expiração de tokens, os limites de requisições, a
+// ociosidade e o agrupamento dos hubs, a duração das requisições nos logs e
+// nas métricas e o uptime de /debug/build. Prazos de I/O da rede continuam no
+// relógio do sistema, já que dizem respeito ao tempo real.
+package clock
+
+import "time"
+
+// Clock informa a hora atual e cria temporizadores
+type Clock interface {
+	Now() time.Time
+	// After envia a hora no canal depois de d, como time.After
+	After(d time.Duration) <-chan time.Time
+	// NewTicker envia a hora no canal a cada d, como time.NewTicker
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker é o temporizador periódico criado por Clock.NewTicker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// System é o relógio do sistema
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTicker(d time.Duration) Ticker       { return systemTicker{time.NewTicker(d)} }
+
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.ticker.C }
+func (t systemTicker) Stop()               { t.ticker.Stop() }
@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake é um relógio que só anda quando o teste chama Advance ou Set. Os
+// temporizadores criados por ele disparam durante o Advance que alcança o seu
+// horário, na ordem desses horários.
+type Fake struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration // zero para After
+	ch     chan time.Time
+}
+
+// NewFake cria um relógio parado em start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, &fakeWaiter{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// NewTicker entra em pânico se d não for positivo, como time.NewTicker
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	waiter := &fakeWaiter{at: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, waiter)
+	return &fakeTicker{clock: f, waiter: waiter}
+}
+
+// Advance avança o relógio e dispara os temporizadores vencidos. Como no
+// time.Ticker, um ticker cujo canal ainda tem um valor não lido perde os
+// disparos seguintes.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set leva o relógio até t; horários anteriores ao atual são ignorados
+func (f *Fake) Set(t time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if t.Before(f.now) {
+		return
+	}
+	for {
+		sort.Slice(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+		if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+			break
+		}
+
+		waiter := f.waiters[0]
+		f.now = waiter.at
+		select {
+		case waiter.ch <- waiter.at:
+		default:
+		}
+		if waiter.period > 0 {
+			waiter.at = waiter.at.Add(waiter.period)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+	}
+	f.now = t
+}
+
+// Waiters retorna quantos temporizadores estão pendentes. Testes podem usá-lo
+// para esperar que uma goroutine crie o seu antes de avançar o relógio.
+func (f *Fake) Waiters() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) stop(waiter *fakeWaiter) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i, w := range f.waiters {
+		if w == waiter {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *Fake
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.waiter.ch }
+func (t *fakeTicker) Stop()               { t.clock.stop(t.waiter) }
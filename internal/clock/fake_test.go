@@ -0,0 +1,65 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeAfter(t *testing.T) {
+	fake := NewFake(start)
+	ch := fake.After(time.Minute)
+
+	fake.Advance(59 * time.Second)
+	if _, ok := fired(ch); ok {
+		t.Fatal("After fired before its deadline")
+	}
+	fake.Advance(time.Second)
+	if at, ok := fired(ch); !ok || !at.Equal(start.Add(time.Minute)) {
+		t.Fatalf("After should fire at %s, got %s (fired %v)", start.Add(time.Minute), at, ok)
+	}
+	if fake.Waiters() != 0 {
+		t.Fatalf("fired timer should be removed, %d left", fake.Waiters())
+	}
+	if _, ok := fired(fake.After(0)); !ok {
+		t.Fatal("After(0) should fire immediately")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	fake := NewFake(start)
+	ticker := fake.NewTicker(time.Second)
+
+	fake.Advance(time.Second)
+	if _, ok := fired(ticker.C()); !ok {
+		t.Fatal("ticker did not fire")
+	}
+
+	// Como no time.Ticker, disparos com o canal cheio são perdidos
+	fake.Advance(3 * time.Second)
+	if at, ok := fired(ticker.C()); !ok || !at.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("expected a single tick at %s, got %s", start.Add(2*time.Second), at)
+	}
+	if _, ok := fired(ticker.C()); ok {
+		t.Fatal("dropped ticks should not be delivered")
+	}
+
+	ticker.Stop()
+	fake.Advance(time.Minute)
+	if _, ok := fired(ticker.C()); ok {
+		t.Fatal("stopped ticker fired")
+	}
+	if !fake.Now().Equal(start.Add(time.Minute + 4*time.Second)) {
+		t.Fatalf("unexpected time %s", fake.Now())
+	}
+}
@@ -0,0 +1,295 @@
+// Package config carrega as configurações do servidor. A precedência, da menor
+// para a maior, é: valores padrão, arquivo (JSON ou YAML), variáveis de
+// ambiente e flags de linha de comando.
+//
+// O arquivo é indicado por -config ou POKER_CONFIG e usa os mesmos nomes dos
+// campos JSON abaixo, por exemplo:
+//
+//	server:
+//	  addr: ":3001"
+//	cors:
+//	  allowedOrigins: ["http://localhost:5173"]
+//	websocket:
+//	  pongWait: 60s
+//	  slowConsumerPolicy: coalesce
+//
+// As variáveis de ambiente usam o prefixo POKER_ (POKER_ADDR,
+// POKER_TOKEN_SECRET...); a lista completa está em -h.
+//
+// Durações aceitam o formato de time.ParseDuration ("50ms", "12h"). Os limites
+// de requisição (rateLimits) só podem ser definidos pelo arquivo.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"flash-cards/backend/internal/handler"
+	"flash-cards/backend/internal/logging"
+	"flash-cards/backend/internal/random"
+	"flash-cards/backend/internal/ratelimit"
+	"flash-cards/backend/internal/websocket"
+)
+
+// Config agrupa todas as configurações do servidor
+type Config struct {
+	Server     ServerConfig       `json:"server"`
+	Log        logging.Options    `json:"log"`
+	CORS       CORSConfig         `json:"cors"`
+	Token      TokenConfig        `json:"token"`
+	Admin      AdminConfig        `json:"admin"`
+	Sessions   SessionsConfig     `json:"sessions"`
+	Websocket  WebsocketConfig    `json:"websocket"`
+	RateLimits handler.RateLimits `json:"rateLimits"`
+}
+
+type ServerConfig struct {
+	// Addr é o endereço em que o servidor escuta. Padrão: ":3001"
+	Addr string `json:"addr"`
+	// ShutdownTimeout é o prazo total do desligamento após SIGINT/SIGTERM. Padrão: 15s
+	ShutdownTimeout Duration `json:"shutdownTimeout"`
+	// ReconnectAfter é o tempo sugerido aos clientes no evento "server_restarting". Padrão: 5s
+	ReconnectAfter Duration `json:"reconnectAfter"`
+	// MaxBodyBytes limita o corpo das requisições; acima dele a resposta é 413. Padrão: 16 KiB
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins são as origens aceitas pelo CORS e pelo upgrade do WebSocket.
+	// Padrão: as origens de desenvolvimento locais.
+	AllowedOrigins []string `json:"allowedOrigins"`
+	// Debug liga os logs do pacote de CORS, no nível debug do log. Padrão: false
+	Debug bool `json:"debug"`
+}
+
+type TokenConfig struct {
+	// Secret é a chave HMAC dos tokens (env POKER_TOKEN_SECRET). Vazia, uma chave
+	// aleatória é gerada a cada inicialização e os tokens deixam de valer quando
+	// o servidor reinicia.
+	Secret string `json:"secret"`
+	// TTL é a validade dos tokens. Padrão: 12h
+	TTL Duration `json:"ttl"`
+}
+
+type AdminConfig struct {
+	// Token protege a área /debug (pprof, hubs e versão). Vazio, a área fica desativada.
+	Token string `json:"token"`
+}
+
+// minAdminTokenLength evita tokens de administrador fáceis de adivinhar
+const minAdminTokenLength = 16
+
+type SessionsConfig struct {
+	// Codes define o formato dos códigos de sessão. Padrão: 6 caracteres de random.DefaultAlphabet
+	Codes random.CodeOptions `json:"codes"`
+	// AllowVanityCodes permite que o owner escolha o código. Padrão: false
+	AllowVanityCodes bool `json:"allowVanityCodes"`
+}
+
+// WebsocketConfig espelha websocket.HubOptions com durações legíveis
+type WebsocketConfig struct {
+	SendBufferSize     int      `json:"sendBufferSize"`
+	HistorySize        int      `json:"historySize"`
+	SlowConsumerPolicy string   `json:"slowConsumerPolicy"`
+	BatchWindow        Duration `json:"batchWindow"`
+	MaxBatchSize       int      `json:"maxBatchSize"`
+	IdleTimeout        Duration `json:"idleTimeout"`
+	WriteWait          Duration `json:"writeWait"`
+	PongWait           Duration `json:"pongWait"`
+	MaxMessageSize     int64    `json:"maxMessageSize"`
+	ReadBufferSize     int      `json:"readBufferSize"`
+	WriteBufferSize    int      `json:"writeBufferSize"`
+}
+
+// Default retorna a configuração padrão
+func Default() Config {
+	hub := websocket.DefaultHubOptions()
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":3001",
+			ShutdownTimeout: Duration(15 * time.Second),
+			ReconnectAfter:  Duration(5 * time.Second),
+			MaxBodyBytes:    16 << 10,
+		},
+		Log: logging.DefaultOptions(),
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000", "http://localhost:5173", "http://127.0.0.1:5500"},
+		},
+		Token: TokenConfig{
+			TTL: Duration(12 * time.Hour),
+		},
+		Sessions: SessionsConfig{
+			Codes: random.DefaultCodeOptions(),
+		},
+		Websocket: WebsocketConfig{
+			SendBufferSize:     hub.SendBufferSize,
+			HistorySize:        hub.HistorySize,
+			SlowConsumerPolicy: string(hub.SlowConsumerPolicy),
+			BatchWindow:        Duration(hub.BatchWindow),
+			MaxBatchSize:       hub.MaxBatchSize,
+			IdleTimeout:        Duration(hub.IdleTimeout),
+			WriteWait:          Duration(hub.WriteWait),
+			PongWait:           Duration(hub.PongWait),
+			MaxMessageSize:     hub.MaxMessageSize,
+			ReadBufferSize:     hub.ReadBufferSize,
+			WriteBufferSize:    hub.WriteBufferSize,
+		},
+		RateLimits: handler.DefaultRateLimits(),
+	}
+}
+
+// HubOptions converte a configuração do WebSocket para as opções do hub
+func (c Config) HubOptions() websocket.HubOptions {
+	return websocket.HubOptions{
+		SendBufferSize:     c.Websocket.SendBufferSize,
+		HistorySize:        c.Websocket.HistorySize,
+		SlowConsumerPolicy: websocket.SlowConsumerPolicy(c.Websocket.SlowConsumerPolicy),
+		BatchWindow:        time.Duration(c.Websocket.BatchWindow),
+		MaxBatchSize:       c.Websocket.MaxBatchSize,
+		IdleTimeout:        time.Duration(c.Websocket.IdleTimeout),
+		WriteWait:          time.Duration(c.Websocket.WriteWait),
+		PongWait:           time.Duration(c.Websocket.PongWait),
+		MaxMessageSize:     c.Websocket.MaxMessageSize,
+		ReadBufferSize:     c.Websocket.ReadBufferSize,
+		WriteBufferSize:    c.Websocket.WriteBufferSize,
+		AllowedOrigins:     c.CORS.AllowedOrigins,
+	}
+}
+
+// Validate retorna todos os problemas encontrados na configuração
+func (c Config) Validate() error {
+	var problems []error
+
+	if c.Server.Addr == "" {
+		problems = append(problems, errors.New("server.addr must not be empty"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, fmt.Errorf("server.shutdownTimeout must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	if c.Server.ReconnectAfter < 0 {
+		problems = append(problems, fmt.Errorf("server.reconnectAfter must not be negative, got %s", c.Server.ReconnectAfter))
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		problems = append(problems, fmt.Errorf("server.maxBodyBytes must be positive, got %d", c.Server.MaxBodyBytes))
+	}
+	if err := c.Log.Validate(); err != nil {
+		problems = append(problems, fmt.Errorf("log: %w", err))
+	}
+	if c.Token.TTL <= 0 {
+		problems = append(problems, fmt.Errorf("token.ttl must be positive, got %s", c.Token.TTL))
+	}
+	if c.Admin.Token != "" && len(c.Admin.Token) < minAdminTokenLength {
+		problems = append(problems, fmt.Errorf("admin.token must have at least %d characters", minAdminTokenLength))
+	}
+	if _, err := random.NewCodeGenerator(c.Sessions.Codes, random.Crypto); err != nil {
+		problems = append(problems, fmt.Errorf("sessions.codes: %w", err))
+	}
+	if err := c.HubOptions().Validate(); err != nil {
+		problems = append(problems, fmt.Errorf("websocket: %w", err))
+	}
+
+	limits := map[string]ratelimit.Limit{
+		"perIp":           c.RateLimits.PerIP,
+		"perUser":         c.RateLimits.PerUser,
+		"perSession":      c.RateLimits.PerSession,
+		"sessionCreation": c.RateLimits.SessionCreation,
+		"failedLookups":   c.RateLimits.FailedLookups,
+	}
+	for name, limit := range limits {
+		if limit.Rate < 0 {
+			problems = append(problems, fmt.Errorf("rateLimits.%s.rate must not be negative", name))
+		}
+		// Com burst zero o bucket nunca tem token e toda requisição é recusada
+		if limit.Rate > 0 && limit.Burst < 1 {
+			problems = append(problems, fmt.Errorf("rateLimits.%s.burst must be at least 1, got %d", name, limit.Burst))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// Load monta a configuração a partir de args (sem o nome do programa), do
+// ambiente e do arquivo indicado, e a valida
+func Load(args []string) (Config, error) {
+	config := Default()
+
+	flags := flag.NewFlagSet("api", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("POKER_CONFIG"), "path to a JSON or YAML config file (env POKER_CONFIG)")
+	values := make(map[string]*flagValue, len(settings))
+	for _, s := range settings {
+		values[s.flag] = &flagValue{boolean: s.boolean}
+		flags.Var(values[s.flag], s.flag, fmt.Sprintf("%s (env %s, default %s)", s.usage, s.env, s.get(config)))
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *path != "" {
+		if err := loadFile(*path, &config); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&config, value); err != nil {
+				return Config{}, fmt.Errorf("env %s: %w", s.env, err)
+			}
+		}
+	}
+	// Ignorar o nome antigo trocaria a chave dos tokens a cada reinício sem aviso
+	if _, ok := os.LookupEnv("TOKEN_SECRET"); ok {
+		return Config{}, errors.New("env TOKEN_SECRET was renamed to POKER_TOKEN_SECRET")
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if setErr := s.set(&config, values[s.flag].value); setErr != nil {
+					err = fmt.Errorf("flag -%s: %w", s.flag, setErr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return config, nil
+}
+
+// loadFile sobrepõe config com o conteúdo do arquivo; campos ausentes mantêm o valor atual
+func loadFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("unsupported config file extension %q, use .json, .yaml or .yml", filepath.Ext(path))
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"flash-cards/backend/internal/ratelimit"
+)
+
+func TestValidateRateLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit ratelimit.Limit
+		err   string
+	}{
+		{"valid", ratelimit.Limit{Rate: 1, Burst: 1}, ""},
+		{"disabled", ratelimit.Limit{}, ""},
+		{"negative rate", ratelimit.Limit{Rate: -1, Burst: 1}, "rateLimits.perIp.rate must not be negative"},
+		{"zero burst", ratelimit.Limit{Rate: 1}, "rateLimits.perIp.burst must be at least 1, got 0"},
+		{"negative burst", ratelimit.Limit{Rate: 1, Burst: -2}, "rateLimits.perIp.burst must be at least 1, got -2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Token.Secret = "config-test-token-secret"
+			cfg.RateLimits.PerIP = test.limit
+
+			err := cfg.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadTokenSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		secret string
+		err    string
+	}{
+		{"prefixed variable", map[string]string{"POKER_TOKEN_SECRET": "from-the-environment"}, "from-the-environment", ""},
+		{"old name", map[string]string{"TOKEN_SECRET": "from-the-environment"}, "", "TOKEN_SECRET was renamed to POKER_TOKEN_SECRET"},
+		{"both names", map[string]string{"TOKEN_SECRET": "old", "POKER_TOKEN_SECRET": "new"}, "", "TOKEN_SECRET was renamed"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(nil)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Token.Secret != test.secret {
+				t.Fatalf("expected secret %q, got %q", test.secret, cfg.Token.Secret)
+			}
+		})
+	}
+}
+
+func TestSettingsUsePrefix(t *testing.T) {
+	for _, s := range settings {
+		if !strings.HasPrefix(s.env, "POKER_") {
+			t.Errorf("setting -%s reads %s, without the POKER_ prefix", s.flag, s.env)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration é um time.Duration que, em JSON e YAML, é escrito como "50ms", "12h"...
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %s", data)
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// setting é uma opção que pode ser definida por flag e variável de ambiente
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	// boolean permite usar a flag sem valor, como em -cors-debug
+	boolean bool
+	get     func(c Config) string
+	set     func(c *Config, value string) error
+}
+
+// flagValue guarda o texto recebido pela flag; a conversão fica com setting.set
+type flagValue struct {
+	value   string
+	boolean bool
+}
+
+func (f *flagValue) String() string     { return f.value }
+func (f *flagValue) Set(v string) error { f.value = v; return nil }
+func (f *flagValue) IsBoolFlag() bool   { return f.boolean }
+
+// settings lista as opções aceitas por flag e ambiente; o nome da flag entra no
+// texto de ajuda junto com a variável e o valor padrão
+var settings = []setting{
+	{
+		flag: "addr", env: "POKER_ADDR", usage: "listen address",
+		get: func(c Config) string { return c.Server.Addr },
+		set: func(c *Config, v string) error { c.Server.Addr = v; return nil },
+	},
+	{
+		flag: "shutdown-timeout", env: "POKER_SHUTDOWN_TIMEOUT", usage: "deadline for a graceful shutdown",
+		get: func(c Config) string { return c.Server.ShutdownTimeout.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Server.ShutdownTimeout, v) },
+	},
+	{
+		flag: "reconnect-after", env: "POKER_RECONNECT_AFTER", usage: "reconnect delay suggested to clients on shutdown",
+		get: func(c Config) string { return c.Server.ReconnectAfter.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Server.ReconnectAfter, v) },
+	},
+	{
+		flag: "max-body-bytes", env: "POKER_MAX_BODY_BYTES", usage: "largest accepted request body",
+		get: func(c Config) string { return strconv.FormatInt(c.Server.MaxBodyBytes, 10) },
+		set: func(c *Config, v string) error { return setInt64(&c.Server.MaxBodyBytes, v) },
+	},
+	{
+		flag: "log-level", env: "POKER_LOG_LEVEL", usage: "debug, info, warn or error",
+		get: func(c Config) string { return c.Log.Level },
+		set: func(c *Config, v string) error { c.Log.Level = v; return nil },
+	},
+	{
+		flag: "log-format", env: "POKER_LOG_FORMAT", usage: "json or text",
+		get: func(c Config) string { return c.Log.Format },
+		set: func(c *Config, v string) error { c.Log.Format = v; return nil },
+	},
+	{
+		flag: "cors-origins", env: "POKER_CORS_ORIGINS", usage: "comma-separated allowed origins",
+		get: func(c Config) string { return strings.Join(c.CORS.AllowedOrigins, ",") },
+		set: func(c *Config, v string) error { c.CORS.AllowedOrigins = splitList(v); return nil },
+	},
+	{
+		flag: "cors-debug", boolean: true, env: "POKER_CORS_DEBUG", usage: "log CORS decisions",
+		get: func(c Config) string { return strconv.FormatBool(c.CORS.Debug) },
+		set: func(c *Config, v string) error { return setBool(&c.CORS.Debug, v) },
+	},
+	{
+		flag: "token-secret", env: "POKER_TOKEN_SECRET", usage: "HMAC key for participant tokens",
+		get: func(c Config) string { return "random per start" },
+		set: func(c *Config, v string) error { c.Token.Secret = v; return nil },
+	},
+	{
+		flag: "token-ttl", env: "POKER_TOKEN_TTL", usage: "participant token lifetime",
+		get: func(c Config) string { return c.Token.TTL.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Token.TTL, v) },
+	},
+	{
+		flag: "admin-token", env: "POKER_ADMIN_TOKEN", usage: "bearer token for the /debug area",
+		get: func(c Config) string { return "none, /debug disabled" },
+		set: func(c *Config, v string) error { c.Admin.Token = v; return nil },
+	},
+	{
+		flag: "code-format", env: "POKER_CODE_FORMAT", usage: "session code format: charset or words",
+		get: func(c Config) string { return c.Sessions.Codes.Format },
+		set: func(c *Config, v string) error { c.Sessions.Codes.Format = v; return nil },
+	},
+	{
+		flag: "code-length", env: "POKER_CODE_LENGTH", usage: "session code length for the charset format",
+		get: func(c Config) string { return strconv.Itoa(c.Sessions.Codes.Length) },
+		set: func(c *Config, v string) error { return setInt(&c.Sessions.Codes.Length, v) },
+	},
+	{
+		flag: "code-alphabet", env: "POKER_CODE_ALPHABET", usage: "session code alphabet for the charset format",
+		get: func(c Config) string { return c.Sessions.Codes.Alphabet },
+		set: func(c *Config, v string) error { c.Sessions.Codes.Alphabet = v; return nil },
+	},
+	{
+		flag: "vanity-codes", boolean: true, env: "POKER_VANITY_CODES", usage: "let owners choose the session code",
+		get: func(c Config) string { return strconv.FormatBool(c.Sessions.AllowVanityCodes) },
+		set: func(c *Config, v string) error { return setBool(&c.Sessions.AllowVanityCodes, v) },
+	},
+	{
+		flag: "ws-max-message-size", env: "POKER_WS_MAX_MESSAGE_SIZE", usage: "max bytes of a message read from a websocket client",
+		get: func(c Config) string { return strconv.FormatInt(c.Websocket.MaxMessageSize, 10) },
+		set: func(c *Config, v string) error { return setInt64(&c.Websocket.MaxMessageSize, v) },
+	},
+	{
+		flag: "ws-pong-wait", env: "POKER_WS_PONG_WAIT", usage: "time a websocket client may go without answering pings",
+		get: func(c Config) string { return c.Websocket.PongWait.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Websocket.PongWait, v) },
+	},
+	{
+		flag: "ws-write-wait", env: "POKER_WS_WRITE_WAIT", usage: "deadline for writing a websocket message",
+		get: func(c Config) string { return c.Websocket.WriteWait.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Websocket.WriteWait, v) },
+	},
+	{
+		flag: "ws-send-buffer", env: "POKER_WS_SEND_BUFFER", usage: "queued messages per websocket client",
+		get: func(c Config) string { return strconv.Itoa(c.Websocket.SendBufferSize) },
+		set: func(c *Config, v string) error { return setInt(&c.Websocket.SendBufferSize, v) },
+	},
+	{
+		flag: "ws-history", env: "POKER_WS_HISTORY", usage: "events kept per session for resuming",
+		get: func(c Config) string { return strconv.Itoa(c.Websocket.HistorySize) },
+		set: func(c *Config, v string) error { return setInt(&c.Websocket.HistorySize, v) },
+	},
+	{
+		flag: "ws-slow-consumer-policy", env: "POKER_WS_SLOW_CONSUMER_POLICY", usage: "drop_oldest, coalesce or disconnect",
+		get: func(c Config) string { return c.Websocket.SlowConsumerPolicy },
+		set: func(c *Config, v string) error { c.Websocket.SlowConsumerPolicy = v; return nil },
+	},
+	{
+		flag: "ws-batch-window", env: "POKER_WS_BATCH_WINDOW", usage: "window for batching card updates, 0 disables",
+		get: func(c Config) string { return c.Websocket.BatchWindow.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Websocket.BatchWindow, v) },
+	},
+	{
+		flag: "ws-idle-timeout", env: "POKER_WS_IDLE_TIMEOUT", usage: "how long a session hub without clients is kept, 0 keeps it",
+		get: func(c Config) string { return c.Websocket.IdleTimeout.String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Websocket.IdleTimeout, v) },
+	},
+	{
+		flag: "trust-forwarded-for", boolean: true, env: "POKER_TRUST_FORWARDED_FOR", usage: "take the client IP from X-Forwarded-For",
+		get: func(c Config) string { return strconv.FormatBool(c.RateLimits.TrustForwardedFor) },
+		set: func(c *Config, v string) error { return setBool(&c.RateLimits.TrustForwardedFor, v) },
+	},
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func setBool(target *bool, value string) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*target = parsed
+	return nil
+}
+
+func setInt(target *int, value string) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*target = parsed
+	return nil
+}
+
+func setInt64(target *int64, value string) error {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	*target = parsed
+	return nil
+}
+
+func setDuration(target *Duration, value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*target = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlToJSON converte o subconjunto de YAML usado em arquivos de configuração
+// para JSON, que é então decodificado na Config: mapas e listas em blocos
+// indentados com espaços, listas na forma [a, b], escalares com ou sem aspas e
+// comentários com #. Âncoras, múltiplos documentos e blocos de texto não são
+// suportados.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var lines []yamlLine
+	for number, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		content := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", number+1)
+		}
+		content = stripComment(content)
+		if content == "" || content == "---" {
+			continue
+		}
+		lines = append(lines, yamlLine{number: number + 1, indent: len(raw) - len(strings.TrimLeft(raw, " ")), content: content})
+	}
+
+	if len(lines) == 0 {
+		return []byte("{}"), nil
+	}
+
+	parser := yamlParser{lines: lines}
+	value, err := parser.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[parser.position].number)
+	}
+	return json.Marshal(value)
+}
+
+type yamlLine struct {
+	number  int
+	indent  int
+	content string
+}
+
+type yamlParser struct {
+	lines    []yamlLine
+	position int
+}
+
+// block lê um mapa ou uma lista cujas linhas têm exatamente a indentação informada
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	if isSequenceItem(p.lines[p.position].content) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	result := make(map[string]interface{})
+	for p.position < len(p.lines) {
+		line := p.lines[p.position]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+
+		key, rest, found := cutKey(line.content)
+		if !found {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+		p.position++
+
+		if rest != "" {
+			value, err := scalarOrFlow(rest, line.number)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+			continue
+		}
+
+		// Sem valor na linha: o valor é o bloco indentado a seguir, ou null
+		if p.position < len(p.lines) && p.lines[p.position].indent > indent {
+			value, err := p.block(p.lines[p.position].indent)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		} else {
+			result[key] = nil
+		}
+	}
+	return result, nil
+}
+
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	result := make([]interface{}, 0)
+	for p.position < len(p.lines) {
+		line := p.lines[p.position]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent || !isSequenceItem(line.content) {
+			return nil, fmt.Errorf("line %d: expected a list item", line.number)
+		}
+
+		item := strings.TrimSpace(strings.TrimPrefix(line.content, "-"))
+		if _, _, isMap := cutKey(item); isMap {
+			return nil, fmt.Errorf("line %d: lists of maps are not supported", line.number)
+		}
+		p.position++
+
+		value, err := scalarOrFlow(item, line.number)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func isSequenceItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// cutKey separa "chave: valor". Chaves entre aspas não são suportadas.
+func cutKey(content string) (string, string, bool) {
+	if strings.HasPrefix(content, "\"") || strings.HasPrefix(content, "'") || strings.HasPrefix(content, "[") {
+		return "", "", false
+	}
+	index := strings.Index(content, ": ")
+	if index < 0 {
+		if !strings.HasSuffix(content, ":") {
+			return "", "", false
+		}
+		index = len(content) - 1
+	}
+	return strings.TrimSpace(content[:index]), strings.TrimSpace(content[index+1:]), true
+}
+
+func scalarOrFlow(text string, number int) (interface{}, error) {
+	if !strings.HasPrefix(text, "[") {
+		return scalar(text, number)
+	}
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("line %d: unterminated list", number)
+	}
+
+	result := make([]interface{}, 0)
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	if inner == "" {
+		return result, nil
+	}
+	for _, item := range splitFlow(inner) {
+		value, err := scalar(strings.TrimSpace(item), number)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// splitFlow separa os itens de uma lista [a, "b, c"] respeitando aspas
+func splitFlow(text string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case quote != 0:
+			if text[i] == quote {
+				quote = 0
+			}
+		case text[i] == '"' || text[i] == '\'':
+			quote = text[i]
+		case text[i] == ',':
+			items = append(items, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, text[start:])
+}
+
+func scalar(text string, number int) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, "\""):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", number, text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", number, text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	switch strings.ToLower(text) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+	if integer, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return integer, nil
+	}
+	if float, err := strconv.ParseFloat(text, 64); err == nil {
+		return float, nil
+	}
+	return text, nil
+}
+
+// stripComment remove um comentário "# ..." que não esteja dentro de aspas
+func stripComment(content string) string {
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		switch {
+		case quote != 0:
+			if content[i] == quote {
+				quote = 0
+			}
+		case content[i] == '"' || content[i] == '\'':
+			quote = content[i]
+		case content[i] == '#' && (i == 0 || content[i-1] == ' '):
+			return strings.TrimRight(content[:i], " ")
+		}
+	}
+	return content
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"empty", "", `{}`},
+		{"only comments", "# nada\n---\n", `{}`},
+		{"scalars", "a: 1\nb: 1.5\nc: text\nd: true\ne: off\nf: ~\ng:", `{"a":1,"b":1.5,"c":"text","d":true,"e":false,"f":null,"g":null}`},
+		{"double quotes", `a: "x: y # z"`, `{"a":"x: y # z"}`},
+		{"escapes in double quotes", `a: "tab\there"`, `{"a":"tab\there"}`},
+		{"single quotes", `a: 'it''s'`, `{"a":"it's"}`},
+		{"quoted keywords stay strings", "a: \"true\"\nb: '10'", `{"a":"true","b":"10"}`},
+		{"comments after values", "a: 1 # um\nb: x#y", `{"a":1,"b":"x#y"}`},
+		{"nested maps", "server:\n  port: 8080\n  tls:\n    enabled: no\nlevel: info",
+			`{"server":{"port":8080,"tls":{"enabled":false}},"level":"info"}`},
+		{"block list", "origins:\n  - http://a\n  - \"http://b\"", `{"origins":["http://a","http://b"]}`},
+		{"inline list", `origins: [a, "b, c", 'd', 3]`, `{"origins":["a","b, c","d",3]}`},
+		{"empty inline list", "origins: []", `{"origins":[]}`},
+		{"windows line endings", "a: 1\r\nb: 2\r\n", `{"a":1,"b":2}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := yamlToJSON([]byte(test.yaml))
+			if err != nil {
+				t.Fatalf("yamlToJSON: %v", err)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("invalid JSON %s: %v", data, err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("yamlToJSON = %s, want %s", data, test.want)
+			}
+		})
+	}
+}
+
+func TestYAMLToJSONRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"tab indentation", "server:\n\tport: 1", "line 2: tabs are not allowed"},
+		{"deeper indentation", "a: 1\n  b: 2", "line 2: unexpected indentation"},
+		{"dedent below the document", "  a: 1\nb: 2", "line 2: unexpected indentation"},
+		{"missing colon", "a: 1\nplain", `line 2: expected "key: value"`},
+		{"duplicate key", "a: 1\na: 2", `line 2: duplicate key "a"`},
+		{"quoted key", `"a": 1`, `line 1: expected "key: value"`},
+		{"list not indented under its key", "origins:\n- a", `line 2: expected "key: value"`},
+		{"map inside a list", "a:\n  - b: 1", "line 2: lists of maps are not supported"},
+		{"map after list items", "a:\n  - 1\n  b: 2", "line 3: expected a list item"},
+		{"unterminated inline list", "a: [1, 2", "line 1: unterminated list"},
+		{"unterminated double quotes", `a: "x`, "line 1: invalid quoted string"},
+		{"unterminated single quotes", "a: 'x", "line 1: invalid quoted string"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := yamlToJSON([]byte(test.yaml))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("yamlToJSON = %s, %v; want error %q", data, err, test.err)
+			}
+		})
+	}
+}
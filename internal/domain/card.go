@@ -7,11 +7,29 @@ type Result struct {
 
 type Card struct {
 	ID          string `json:"id"`
+	SessionID   string `json:"sessionId"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Votes são as notas de Ballots, na ordem do primeiro voto de cada participante
+	Votes  []int  `json:"votes"`
+	Result Result `json:"result"`
+	Closed bool   `json:"closed"`
+	// Ballots guarda um voto por participante; não é exposto para não revelar
+	// quem votou o quê
+	Ballots []Ballot `json:"-"`
+}
+
+// Ballot é o voto de um participante num card
+type Ballot struct {
+	UserID string
+	Score  int
+}
+
+// CreateCardRequest traz apenas o que o cliente pode definir num card novo;
+// ID, votos, resultado e encerramento pertencem ao servidor
+type CreateCardRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
-	Votes       []int  `json:"votes"`
-	Result      Result `json:"result"`
-	Closed      bool   `json:"closed"`
 }
 
 type Vote struct {
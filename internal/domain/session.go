@@ -14,10 +14,13 @@ const (
 type UserRole string
 
 const (
-	UserRoleOwner UserRole = "OWNER"
-	UserRoleGuest UserRole = "GUEST"
+	UserRoleOwner       UserRole = "OWNER"
+	UserRoleFacilitator UserRole = "FACILITATOR"
+	UserRoleGuest       UserRole = "GUEST"
 )
 
+// Session não guarda cards: Cards é preenchido a partir do repositório de cards
+// pelo serviço, em toda leitura
 type Session struct {
 	ID        string       `json:"id"`
 	Code      string       `json:"code"`
@@ -26,6 +29,8 @@ type Session struct {
 	OwnerID   string       `json:"ownerId"`
 	Cards     []Card       `json:"cards"`
 	Users     []User       `json:"users"`
+	// Version é incrementada a cada alteração, exceto votos, e exposta como ETag
+	Version uint64 `json:"version"`
 }
 
 type User struct {
@@ -41,19 +46,35 @@ type JoinSessionRequest struct {
 	UserName string `json:"userName"`
 }
 
+// Os nomes dos pedidos abaixo chegam como o cliente os enviou; o serviço os
+// normaliza e valida antes de usá-los
+
 type CreateSessionRequest struct {
 	OwnerName string `json:"ownerName"`
+	// Code é um código personalizado opcional, para salas recorrentes de um time
+	Code string `json:"code,omitempty"`
 }
 
 type CreateSessionResponse struct {
 	Session Session `json:"session"`
 	Code    string  `json:"code"`
+	Token   string  `json:"token"`
+}
+
+// JoinSessionResponse mantém os campos do usuário no topo do JSON e acrescenta o token
+type JoinSessionResponse struct {
+	User
+	Token string `json:"token"`
 }
 
 type UpdateSessionStateRequest struct {
 	State SessionState `json:"state"`
 }
 
+type UpdateUserRoleRequest struct {
+	Role UserRole `json:"role"`
+}
+
 // Métodos auxiliares para Session
 func (s *Session) IsOwner(userID string) bool {
 	return s.OwnerID == userID
@@ -68,6 +89,12 @@ func (s *Session) GetUser(userID string) *User {
 	return nil
 }
 
+// CanFacilitate indica se o usuário pode conduzir a votação (owner ou facilitador)
+func (s *Session) CanFacilitate(userID string) bool {
+	user := s.GetUser(userID)
+	return user != nil && (user.Role == UserRoleOwner || user.Role == UserRoleFacilitator)
+}
+
 func (s *Session) AddUser(user User) {
 	s.Users = append(s.Users, user)
 }
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+// APIPrefix é o prefixo da versão atual da API
+const APIPrefix = "/api/v1"
+
+// RouteRegistrar é implementado pelos handlers da API
+type RouteRegistrar interface {
+	RegisterRoutes(router *mux.Router)
+}
+
+// RegisterAPI registra as rotas dos handlers sob APIPrefix e, como aliases
+// obsoletos, também na raiz, onde ficavam antes do versionamento. As respostas
+// dos aliases trazem os headers Deprecation e Link apontando para a rota nova.
+// O documento OpenAPI fica em /api/v1/openapi.json e em /openapi.json.
+// Caminhos e métodos sem rota respondem ROUTE_NOT_FOUND e METHOD_NOT_ALLOWED.
+func RegisterAPI(router *mux.Router, handlers ...RouteRegistrar) {
+	methodNotAllowed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondWithError(w, r, apperror.New(apperror.CodeMethodNotAllowed))
+	})
+	router.MethodNotAllowedHandler = methodNotAllowed
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if matchesOtherMethod(router, r) {
+			methodNotAllowed(w, r)
+			return
+		}
+		respondWithError(w, r, apperror.New(apperror.CodeRouteNotFound))
+	})
+
+	router.Handle(APIPrefix+"/openapi.json", openapi.Handler()).Methods("GET")
+	router.Handle("/openapi.json", openapi.Handler()).Methods("GET")
+
+	v1 := router.PathPrefix(APIPrefix).Subrouter()
+	legacy := router.NewRoute().Subrouter()
+	legacy.Use(deprecated)
+
+	for _, h := range handlers {
+		h.RegisterRoutes(v1)
+		h.RegisterRoutes(legacy)
+	}
+}
+
+// routeMethods são os métodos testados por matchesOtherMethod
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// matchesOtherMethod informa se o caminho da requisição tem rota para outro
+// método. O mux perde o erro de método quando uma rota de subrouter herda o
+// prefixo do pai, então a requisição chega ao NotFoundHandler mesmo quando o
+// caminho existe.
+func matchesOtherMethod(router *mux.Router, r *http.Request) bool {
+	for _, method := range routeMethods {
+		if method == r.Method {
+			continue
+		}
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecated marca as respostas das rotas sem versão
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+APIPrefix+r.URL.Path+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeTemplate retorna o template da rota da requisição sem APIPrefix, como
+// aparece no documento OpenAPI, ou "" se nenhuma rota corresponde
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(path, APIPrefix)
+}
+
+// RequestValidator confere os corpos JSON das requisições contra o documento OpenAPI
+type RequestValidator struct {
+	validator    *openapi.Validator
+	maxBodyBytes int64
+}
+
+// NewRequestValidator cria o middleware; corpos acima de maxBodyBytes recebem 413
+func NewRequestValidator(validator *openapi.Validator, maxBodyBytes int64) *RequestValidator {
+	return &RequestValidator{validator: validator, maxBodyBytes: maxBodyBytes}
+}
+
+// Middleware deve ser registrado com router.Use, para que a rota esteja
+// disponível. Responde 413 para corpos grandes demais e 400 com um erro por
+// campo para os que não seguem o schema; o corpo lido é devolvido à requisição
+// para o handler. O limite de tamanho vale também para operações sem corpo
+// declarado: quem ler o corpo delas recebe um *http.MaxBytesError.
+func (v *RequestValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, v.maxBodyBytes)
+
+		template := routeTemplate(r)
+		if template == "" || !v.validator.HasBody(r.Method, template) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			respondWithError(w, r, apperror.New(apperror.CodePayloadTooLarge))
+			return
+		case err != nil:
+			respondWithError(w, r, errInvalidPayload)
+			return
+		}
+		if problems := v.validator.ValidateBody(r.Method, template, data); len(problems) > 0 {
+			fields := make([]apperror.Field, len(problems))
+			for i, problem := range problems {
+				fields[i] = apperror.Field{Field: problem.Field, Message: problem.Message}
+			}
+			respondWithError(w, r, errInvalidPayload.WithFields(fields...))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequestValidatorLimitsEveryBody(t *testing.T) {
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"declared body within the limit", http.MethodPost, "/sessions/ABCD12/join", `{"userName":"Ana"}`, http.StatusOK},
+		{"declared body too large", http.MethodPost, "/sessions/ABCD12/join", strings.Repeat("x", 64), http.StatusRequestEntityTooLarge},
+		{"undeclared body within the limit", http.MethodPost, "/sessions/ABCD12/leave", "{}", http.StatusOK},
+		{"undeclared body too large", http.MethodPost, "/sessions/ABCD12/leave", strings.Repeat("x", 64), http.StatusRequestEntityTooLarge},
+		{"route outside the document", http.MethodPost, "/other", strings.Repeat("x", 64), http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// O handler lê o corpo mesmo quando o documento não declara um
+			read := func(w http.ResponseWriter, r *http.Request) {
+				var tooLarge *http.MaxBytesError
+				if _, err := io.ReadAll(r.Body); errors.As(err, &tooLarge) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				}
+			}
+			router := mux.NewRouter()
+			router.Use(NewRequestValidator(validator, 32).Middleware)
+			router.HandleFunc("/sessions/{code}/join", read)
+			router.HandleFunc("/sessions/{code}/leave", read)
+			router.HandleFunc("/other", read)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+			if recorder.Code != test.status {
+				t.Fatalf("%s %s returned %d, want %d", test.method, test.path, recorder.Code, test.status)
+			}
+		})
+	}
+}
+
+// routes registra handlers vazios nos caminhos do documento usados nos testes
+type routes struct{}
+
+func (routes) RegisterRoutes(router *mux.Router) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.HandleFunc("/sessions", ok).Methods("POST")
+	router.HandleFunc("/sessions/{code}/state", ok).Methods("PUT")
+	router.HandleFunc("/sessions/{code}/cards/{id}/vote", ok).Methods("POST")
+	router.HandleFunc("/sessions/{code}/users/{userId}/role", ok).Methods("PUT")
+}
+
+func TestRequestValidatorErrorCodes(t *testing.T) {
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	router.Use(NewRequestValidator(validator, 256).Middleware)
+	RegisterAPI(router, routes{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   apperror.Code
+		field  string
+	}{
+		{"valid body", "POST", "/api/v1/sessions", `{"ownerName":"Ana"}`, http.StatusOK, "", ""},
+		{"required field", "POST", "/api/v1/sessions", `{}`, http.StatusBadRequest, apperror.CodeInvalidRequest, "ownerName"},
+		{"wrong type", "POST", "/api/v1/sessions/ABCD12/cards/c1/vote", `{"score":"5"}`, http.StatusBadRequest, apperror.CodeInvalidRequest, "score"},
+		{"enum", "PUT", "/api/v1/sessions/ABCD12/state", `{"state":"PAUSED"}`, http.StatusBadRequest, apperror.CodeInvalidRequest, "state"},
+		{"enum on the legacy route", "PUT", "/sessions/ABCD12/users/u1/role", `{"role":"OWNER"}`, http.StatusBadRequest, apperror.CodeInvalidRequest, "role"},
+		{"maxLength", "POST", "/api/v1/sessions", `{"ownerName":"` + strings.Repeat("a", 41) + `"}`, http.StatusBadRequest, apperror.CodeInvalidRequest, "ownerName"},
+		{"invalid JSON", "POST", "/api/v1/sessions", `{`, http.StatusBadRequest, apperror.CodeInvalidRequest, ""},
+		{"body too large", "POST", "/api/v1/sessions", `{"ownerName":"` + strings.Repeat("a", 256) + `"}`, http.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, ""},
+		{"unknown path", "POST", "/api/v1/sessions/ABCD12/unknown", `{}`, http.StatusNotFound, apperror.CodeRouteNotFound, ""},
+		{"unknown method", "DELETE", "/api/v1/sessions", "", http.StatusMethodNotAllowed, apperror.CodeMethodNotAllowed, ""},
+		{"unknown method on the legacy route", "GET", "/sessions/ABCD12/state", "", http.StatusMethodNotAllowed, apperror.CodeMethodNotAllowed, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+			if recorder.Code != test.status {
+				t.Fatalf("%s %s returned %d, want %d: %s", test.method, test.path, recorder.Code, test.status, recorder.Body)
+			}
+			if test.code == "" {
+				return
+			}
+
+			var response apperror.Response
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("error body %q: %v", recorder.Body, err)
+			}
+			if response.Code != test.code {
+				t.Fatalf("code = %s, want %s", response.Code, test.code)
+			}
+			if test.field == "" {
+				return
+			}
+			if len(response.Fields) != 1 || response.Fields[0].Field != test.field {
+				t.Fatalf("fields = %+v, want only %q", response.Fields, test.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/logging"
+	"flash-cards/backend/internal/service"
+)
+
+// bearerToken extrai o token do header Authorization. Só o upgrade do
+// WebSocket aceita o parâmetro de query "token", já que navegadores não enviam
+// headers nele; nas demais rotas o token na URL acabaria em logs de proxies e
+// no histórico do navegador.
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if isRoute(r, websocketRoute) {
+		return r.URL.Query().Get("token")
+	}
+	return ""
+}
+
+// authenticate valida o token da requisição e confere que ele foi emitido para
+// a sessão informada. Em caso de falha já responde 401 e retorna false.
+func authenticate(w http.ResponseWriter, r *http.Request, tokens *service.TokenService, sessionCode string) (service.Claims, bool) {
+	claims, ok := verifyToken(w, r, tokens)
+	if !ok {
+		return service.Claims{}, false
+	}
+
+	if claims.SessionCode != sessionCode {
+		respondWithError(w, r, apperror.New(apperror.CodeTokenWrongSession))
+		return service.Claims{}, false
+	}
+
+	return claims, true
+}
+
+// verifyToken valida o token da requisição sem conferir a sessão
+func verifyToken(w http.ResponseWriter, r *http.Request, tokens *service.TokenService) (service.Claims, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		respondWithError(w, r, apperror.New(apperror.CodeTokenRequired))
+		return service.Claims{}, false
+	}
+
+	claims, err := tokens.Verify(token)
+	if err != nil {
+		respondWithError(w, r, err)
+		return service.Claims{}, false
+	}
+
+	logging.AddFields(r.Context(), logging.KeyUserID, claims.UserID)
+	return claims, true
+}
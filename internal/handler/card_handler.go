@@ -2,114 +2,54 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
-	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/apperror"
+
 	"flash-cards/backend/internal/service"
 
 	"github.com/gorilla/mux"
 )
 
 type CardHandler struct {
-	service           *service.CardService
-	websocketService  *service.WebsocketService
+	service      *service.CardService
+	tokenService *service.TokenService
 }
 
-func NewCardHandler(service *service.CardService, websocketService *service.WebsocketService) *CardHandler {
+func NewCardHandler(service *service.CardService, tokenService *service.TokenService) *CardHandler {
 	return &CardHandler{
-		service:           service,
-		websocketService:  websocketService,
+		service:      service,
+		tokenService: tokenService,
 	}
 }
 
 func (h *CardHandler) RegisterRoutes(router *mux.Router) {
+	// Cards pertencem a uma sessão: criar, votar, encerrar e resetar ficam nas
+	// rotas de sessão. GET /cards retorna os cards da sessão do token.
 	router.HandleFunc("/cards", h.GetCards).Methods("GET")
-	router.HandleFunc("/cards", h.CreateCard).Methods("POST")
-	router.HandleFunc("/cards/{id}/close", h.CloseVoting).Methods("POST")
-	router.HandleFunc("/cards/reset-all", h.ResetAllVotings).Methods("POST")
-	router.HandleFunc("/cards/{id}/vote", h.Vote).Methods("POST")
 }
 
 func (h *CardHandler) GetCards(w http.ResponseWriter, r *http.Request) {
-	cards := h.service.GetAllCards()
-	respondWithJSON(w, http.StatusOK, cards)
-}
-
-func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
-	var card domain.Card
-	if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
-
-	card = h.service.CreateCard(card)
-	
-	// Broadcast da atualização para todos os clientes conectados à sessão
-	sessionCode := r.Header.Get("Session-Code")
-	if sessionCode != "" {
-		h.websocketService.BroadcastCard(sessionCode, card)
-	}
-	
-	respondWithJSON(w, http.StatusCreated, card)
-}
-
-func (h *CardHandler) Vote(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	var vote domain.Vote
-	if err := json.NewDecoder(r.Body).Decode(&vote); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	claims, ok := verifyToken(w, r, h.tokenService)
+	if !ok {
 		return
 	}
 
-	card, err := h.service.AddVote(params["id"], vote)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
-		return
-	}
-
-	// Broadcast da atualização para todos os clientes conectados à sessão
-	sessionCode := r.Header.Get("Session-Code")
-	if sessionCode != "" {
-		h.websocketService.BroadcastCard(sessionCode, card)
-	}
-
-	respondWithJSON(w, http.StatusOK, card)
-}
-
-func (h *CardHandler) CloseVoting(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	card, err := h.service.CloseVoting(params["id"])
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
-		return
-	}
-
-	// Broadcast da atualização para todos os clientes conectados à sessão
-	sessionCode := r.Header.Get("Session-Code")
-	if sessionCode != "" {
-		h.websocketService.BroadcastCard(sessionCode, card)
-	}
-
-	respondWithJSON(w, http.StatusOK, card)
-}
-
-func (h *CardHandler) ResetAllVotings(w http.ResponseWriter, r *http.Request) {
-	h.service.ResetAllVotes()
-	cards := h.service.GetAllCards()
-	
-	// Broadcast da atualização para todos os clientes conectados à sessão
-	sessionCode := r.Header.Get("Session-Code")
-	if sessionCode != "" {
-		for _, card := range cards {
-			h.websocketService.BroadcastCard(sessionCode, card)
-		}
-	}
-	
+	cards := h.service.GetSessionCards(claims.SessionID)
 	respondWithJSON(w, http.StatusOK, cards)
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// Erros dos próprios handlers; os dos serviços já chegam como *apperror.Error
+var (
+	errInvalidPayload = apperror.New(apperror.CodeInvalidRequest)
+	errOwnerNotFound  = errors.New("session owner not found")
+)
+
+// respondWithError responde com o código, o status e a mensagem localizada do
+// erro; veja apperror.Write
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	apperror.Write(w, r, err)
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// BuildInfo identifica o binário; os campos vêm de -ldflags "-X main.version=..."
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+}
+
+// DebugHandler expõe a área /debug: pprof, os hubs ativos e a versão do
+// binário. Todas as rotas exigem o token de administrador.
+type DebugHandler struct {
+	adminToken       string
+	websocketService *service.WebsocketService
+	build            BuildInfo
+	clock            clock.Clock
+	startedAt        time.Time
+}
+
+// NewDebugHandler cria o handler de diagnóstico; com adminToken vazio a área
+// /debug não é registrada. O início e o uptime informados vêm do clock.
+func NewDebugHandler(adminToken string, websocketService *service.WebsocketService, build BuildInfo, clock clock.Clock) *DebugHandler {
+	return &DebugHandler{
+		adminToken:       adminToken,
+		websocketService: websocketService,
+		build:            build,
+		clock:            clock,
+		startedAt:        clock.Now(),
+	}
+}
+
+// HubDump descreve o hub de uma sessão
+type HubDump struct {
+	Session      string `json:"session"`
+	Clients      int    `json:"clients"`
+	Published    uint64 `json:"published"`
+	Dropped      uint64 `json:"dropped"`
+	Coalesced    uint64 `json:"coalesced"`
+	Disconnected uint64 `json:"disconnected"`
+	Batched      uint64 `json:"batched"`
+}
+
+// HubsResponse é a resposta de /debug/hubs, ordenada pelo código da sessão
+type HubsResponse struct {
+	Hubs    []HubDump `json:"hubs"`
+	Clients int       `json:"clients"`
+}
+
+// BuildResponse é a resposta de /debug/build
+type BuildResponse struct {
+	BuildInfo
+	GoVersion  string            `json:"goVersion"`
+	Module     string            `json:"module,omitempty"`
+	Settings   map[string]string `json:"settings,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	Uptime     string            `json:"uptime"`
+	Goroutines int               `json:"goroutines"`
+}
+
+// RegisterRoutes registra as rotas de /debug, se houver token de administrador
+func (h *DebugHandler) RegisterRoutes(router *mux.Router) {
+	if h.adminToken == "" {
+		return
+	}
+
+	debugRouter := router.PathPrefix("/debug").Subrouter()
+	debugRouter.Use(h.requireAdmin)
+
+	debugRouter.HandleFunc("/hubs", h.Hubs).Methods("GET")
+	debugRouter.HandleFunc("/build", h.Build).Methods("GET")
+
+	debugRouter.HandleFunc("/pprof/cmdline", pprof.Cmdline)
+	debugRouter.HandleFunc("/pprof/profile", pprof.Profile)
+	debugRouter.HandleFunc("/pprof/symbol", pprof.Symbol)
+	debugRouter.HandleFunc("/pprof/trace", pprof.Trace)
+	// Index também serve os perfis nomeados, como /debug/pprof/heap
+	debugRouter.PathPrefix("/pprof/").HandlerFunc(pprof.Index)
+}
+
+// requireAdmin exige "Authorization: Bearer <token de administrador>"
+func (h *DebugHandler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="debug"`)
+			respondWithError(w, r, apperror.New(apperror.CodeAdminTokenRequired))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Hubs lista os hubs ativos com a contagem de clientes e os contadores de cada um
+func (h *DebugHandler) Hubs(w http.ResponseWriter, r *http.Request) {
+	stats := h.websocketService.Stats()
+
+	response := HubsResponse{Hubs: make([]HubDump, 0, len(stats))}
+	for code, hub := range stats {
+		response.Hubs = append(response.Hubs, HubDump{
+			Session:      code,
+			Clients:      hub.Clients,
+			Published:    hub.Published,
+			Dropped:      hub.Dropped,
+			Coalesced:    hub.Coalesced,
+			Disconnected: hub.Disconnected,
+			Batched:      hub.Batched,
+		})
+		response.Clients += hub.Clients
+	}
+	sort.Slice(response.Hubs, func(i, j int) bool {
+		return response.Hubs[i].Session < response.Hubs[j].Session
+	})
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Build retorna a versão do binário, a versão do Go e as informações de VCS
+// gravadas pelo compilador
+func (h *DebugHandler) Build(w http.ResponseWriter, r *http.Request) {
+	response := BuildResponse{
+		BuildInfo:  h.build,
+		GoVersion:  runtime.Version(),
+		StartedAt:  h.startedAt,
+		Uptime:     h.clock.Now().Sub(h.startedAt).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		response.Module = info.Main.Path
+		response.Settings = make(map[string]string)
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified", "GOOS", "GOARCH", "CGO_ENABLED":
+				response.Settings[setting.Key] = setting.Value
+			}
+		}
+		if response.Commit == "" {
+			response.Commit = response.Settings["vcs.revision"]
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"flash-cards/backend/internal/service"
+)
+
+// etag formata a versão da sessão como ETag
+func etag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// ifMatchVersion lê a versão esperada do header If-Match. Sem o header, ou com
+// "*", retorna zero, que desativa a verificação. Um valor que não é uma versão
+// nunca corresponde à sessão atual, então responde 412 e retorna false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	value := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || version == 0 {
+		respondWithError(w, r, service.ErrVersionMismatch)
+		return 0, false
+	}
+	return version, true
+}
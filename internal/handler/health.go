@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"flash-cards/backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// readinessTimeout limita cada verificação de /readyz; um repositório travado
+// deve tirar a instância do balanceamento em vez de travar também a sonda
+const readinessTimeout = 2 * time.Second
+
+var errCheckTimeout = errors.New("check timed out")
+
+// HealthHandler responde às sondas do orquestrador
+type HealthHandler struct {
+	sessionService   *service.SessionService
+	websocketService *service.WebsocketService
+	shutdownGate     *ShutdownGate
+}
+
+// NewHealthHandler cria uma nova instância do handler de saúde
+func NewHealthHandler(sessionService *service.SessionService, websocketService *service.WebsocketService, shutdownGate *ShutdownGate) *HealthHandler {
+	return &HealthHandler{
+		sessionService:   sessionService,
+		websocketService: websocketService,
+		shutdownGate:     shutdownGate,
+	}
+}
+
+// ReadinessResponse traz o resultado de cada verificação: "ok" ou a mensagem de erro
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// RegisterRoutes registra /healthz e /readyz
+func (h *HealthHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/healthz", h.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", h.Readiness).Methods("GET")
+}
+
+// Liveness responde 200 enquanto o processo consegue atender requisições
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness responde 200 se o armazenamento responde, os hubs aceitam conexões e
+// o servidor não está desligando; caso contrário 503, com o motivo de cada falha
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func() error{
+		"storage": h.sessionService.Ping,
+		"bus":     h.websocketService.Ping,
+		"draining": func() error {
+			if h.shutdownGate.Draining() {
+				return service.ErrShuttingDown
+			}
+			return nil
+		},
+	}
+
+	response := ReadinessResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+	for name, check := range checks {
+		if err := runCheck(check); err != nil {
+			response.Status = "unavailable"
+			response.Checks[name] = err.Error()
+			continue
+		}
+		response.Checks[name] = "ok"
+	}
+
+	status := http.StatusOK
+	if response.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, status, response)
+}
+
+// runCheck executa a verificação com o prazo de readinessTimeout. Se o prazo
+// vencer, a goroutine fica presa até a verificação retornar.
+func runCheck(check func() error) error {
+	result := make(chan error, 1)
+	go func() { result <- check() }()
+
+	timer := time.NewTimer(readinessTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return errCheckTimeout
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/logging"
+	"flash-cards/backend/internal/random"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRequestIDLength limita o X-Request-ID aceito do cliente ou do proxy
+const maxRequestIDLength = 128
+
+// RequestLogger atribui um request ID a cada requisição, coloca no contexto um
+// logger com os campos de correlação e registra a requisição ao final
+type RequestLogger struct {
+	logger *slog.Logger
+	ids    random.IDGenerator
+	clock  clock.Clock
+}
+
+// NewRequestLogger cria o middleware; a duração registrada é medida no clock
+func NewRequestLogger(logger *slog.Logger, ids random.IDGenerator, clock clock.Clock) *RequestLogger {
+	return &RequestLogger{logger: logger, ids: ids, clock: clock}
+}
+
+// Middleware deve ser o primeiro registrado com router.Use, para que os demais
+// já encontrem o logger no contexto
+func (l *RequestLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.clock.Now()
+
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = l.ids.NewID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		attrs := []any{logging.KeyRequestID, requestID}
+		vars := mux.Vars(r)
+		if code := vars["code"]; code != "" {
+			attrs = append(attrs, logging.KeySession, code)
+		} else if code := vars["sessionCode"]; code != "" {
+			attrs = append(attrs, logging.KeySession, code)
+		}
+		r = r.WithContext(logging.NewContext(r.Context(), l.logger, attrs...))
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		level := slog.LevelInfo
+		switch {
+		case recorder.status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case isRoute(r, "/healthz") || isRoute(r, "/readyz"):
+			// As sondas do orquestrador chegam a cada poucos segundos
+			level = slog.LevelDebug
+		}
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		logging.FromContext(r.Context()).Log(r.Context(), level, "request",
+			"method", r.Method,
+			"route", route,
+			"status", recorder.status,
+			"duration_ms", float64(l.clock.Now().Sub(start).Microseconds())/1000,
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
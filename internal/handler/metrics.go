@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/metrics"
+	"flash-cards/backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// hubClientBuckets são os limites do histograma de clientes por hub
+var hubClientBuckets = []float64{0, 1, 2, 5, 10, 20, 50, 100}
+
+// Metrics registra as métricas do servidor e mede a latência das requisições
+type Metrics struct {
+	registry        *metrics.Registry
+	requestDuration *metrics.Histogram
+	clock           clock.Clock
+}
+
+// NewMetrics registra no registry as métricas de sessões, hubs, votos, limites
+// de requisição e do runtime. As de sessões e hubs são lidas no momento da coleta;
+// a latência das requisições é medida no clock.
+func NewMetrics(registry *metrics.Registry, sessionService *service.SessionService, websocketService *service.WebsocketService, rateLimiter *RateLimiter, clock clock.Clock) *Metrics {
+	m := &Metrics{
+		registry: registry,
+		clock:    clock,
+		requestDuration: registry.Histogram("poker_http_request_duration_seconds",
+			"Duration of HTTP requests by route template, method and status.",
+			metrics.DefaultBuckets, "route", "method", "status"),
+	}
+
+	registry.GaugeFunc("poker_sessions", "Sessions in memory by state.", []string{"state"}, func() []metrics.Sample {
+		stats := sessionService.Stats()
+		samples := make([]metrics.Sample, 0, len(stats.ByState))
+		for state, count := range stats.ByState {
+			samples = append(samples, metrics.Sample{LabelValues: []string{string(state)}, Value: float64(count)})
+		}
+		return samples
+	})
+	registry.GaugeFunc("poker_session_users", "Participants across all sessions.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(sessionService.Stats().Users)}}
+	})
+	registry.CounterFunc("poker_votes_total", "Votes cast.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(sessionService.VotesCast())}}
+	})
+	registry.CounterFunc("poker_session_codes_generated_total", "Session codes generated, including collisions.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(sessionService.CodeStats().Generated)}}
+	})
+	registry.CounterFunc("poker_session_code_collisions_total", "Generated session codes that were already in use.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(sessionService.CodeStats().Collisions)}}
+	})
+
+	registry.GaugeFunc("poker_hubs", "Active websocket hubs.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(len(websocketService.Stats()))}}
+	})
+	// Os hubs não são identificados: /metrics é público e o código da sessão dá
+	// acesso a ela. O detalhe por hub fica em /debug/hubs, que exige o token de
+	// administrador.
+	registry.GaugeFunc("poker_ws_clients", "Connected websocket clients across all hubs.", nil, func() []metrics.Sample {
+		var clients int
+		for _, hub := range websocketService.Stats() {
+			clients += hub.Clients
+		}
+		return []metrics.Sample{{Value: float64(clients)}}
+	})
+	registry.HistogramFunc("poker_hub_clients", "Distribution of connected websocket clients per hub.", hubClientBuckets, func() []float64 {
+		stats := websocketService.Stats()
+		values := make([]float64, 0, len(stats))
+		for _, hub := range stats {
+			values = append(values, float64(hub.Clients))
+		}
+		return values
+	})
+	registry.CounterFunc("poker_ws_messages_published_total", "Events published by all hubs.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(websocketService.Totals().Published)}}
+	})
+	registry.CounterFunc("poker_ws_messages_dropped_total", "Messages dropped from full client queues.", []string{"reason"}, func() []metrics.Sample {
+		totals := websocketService.Totals()
+		return []metrics.Sample{
+			{LabelValues: []string{"drop_oldest"}, Value: float64(totals.Dropped)},
+			{LabelValues: []string{"coalesce"}, Value: float64(totals.Coalesced)},
+		}
+	})
+	registry.CounterFunc("poker_ws_messages_batched_total", "Updates merged into batches instead of sent alone.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(websocketService.Totals().Batched)}}
+	})
+	registry.CounterFunc("poker_ws_disconnects_total", "Clients disconnected by the server.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(websocketService.Totals().Disconnected)}}
+	})
+
+	registry.CounterFunc("poker_rate_limit_rejected_total", "Requests rejected by rate limiting, by limiter.", []string{"limiter"}, func() []metrics.Sample {
+		stats := rateLimiter.Stats()
+		samples := make([]metrics.Sample, 0, len(stats))
+		for name, limiter := range stats {
+			samples = append(samples, metrics.Sample{LabelValues: []string{name}, Value: float64(limiter.Rejected)})
+		}
+		return samples
+	})
+
+	registry.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(runtime.NumGoroutine())}}
+	})
+	registry.GaugeFunc("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", nil, func() []metrics.Sample {
+		var memory runtime.MemStats
+		runtime.ReadMemStats(&memory)
+		return []metrics.Sample{{Value: float64(memory.HeapAlloc)}}
+	})
+
+	return m
+}
+
+// RegisterRoutes registra a rota /metrics
+func (m *Metrics) RegisterRoutes(router *mux.Router) {
+	router.Handle("/metrics", m.registry.Handler()).Methods("GET")
+}
+
+// Middleware mede a duração das requisições; deve ser registrado com router.Use
+// antes dos demais, para medir também as respostas dadas por eles
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := m.clock.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		m.requestDuration.Observe(m.clock.Now().Sub(start).Seconds(), route, r.Method, strconv.Itoa(recorder.status))
+	})
+}
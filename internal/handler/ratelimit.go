@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/ratelimit"
+	"flash-cards/backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimits agrupa os limites aplicados às requisições
+type RateLimits struct {
+	// PerIP e PerUser valem para todas as rotas; PerSession, para as rotas com
+	// código autenticadas com um token daquela sessão
+	PerIP      ratelimit.Limit `json:"perIp"`
+	PerUser    ratelimit.Limit `json:"perUser"`
+	PerSession ratelimit.Limit `json:"perSession"`
+	// SessionCreation limita POST /sessions por IP
+	SessionCreation ratelimit.Limit `json:"sessionCreation"`
+	// FailedLookups limita, por IP, as buscas por códigos de sessão inexistentes.
+	// Esgotado, o IP recebe 429 em qualquer rota com código até o bucket encher.
+	FailedLookups ratelimit.Limit `json:"failedLookups"`
+	// TrustForwardedFor usa o primeiro IP de X-Forwarded-For, para quando há um proxy na frente
+	TrustForwardedFor bool `json:"trustForwardedFor"`
+}
+
+// DefaultRateLimits retorna os limites padrão
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		PerIP:           ratelimit.Per(20, time.Second, 40),
+		PerUser:         ratelimit.Per(10, time.Second, 20),
+		PerSession:      ratelimit.Per(50, time.Second, 100),
+		SessionCreation: ratelimit.Per(10, time.Minute, 5),
+		FailedLookups:   ratelimit.Per(5, time.Minute, 10),
+	}
+}
+
+// RateLimiter aplica os limites como middleware do router
+type RateLimiter struct {
+	limits        RateLimits
+	tokenService  *service.TokenService
+	ip            *ratelimit.Limiter
+	user          *ratelimit.Limiter
+	session       *ratelimit.Limiter
+	creation      *ratelimit.Limiter
+	failedLookups *ratelimit.Limiter
+}
+
+func NewRateLimiter(limits RateLimits, tokenService *service.TokenService, clock clock.Clock) *RateLimiter {
+	return &RateLimiter{
+		limits:        limits,
+		tokenService:  tokenService,
+		ip:            ratelimit.NewLimiter(limits.PerIP, clock),
+		user:          ratelimit.NewLimiter(limits.PerUser, clock),
+		session:       ratelimit.NewLimiter(limits.PerSession, clock),
+		creation:      ratelimit.NewLimiter(limits.SessionCreation, clock),
+		failedLookups: ratelimit.NewLimiter(limits.FailedLookups, clock),
+	}
+}
+
+// Middleware deve ser registrado com router.Use, para que as variáveis da rota estejam disponíveis
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := l.clientIP(r)
+		vars := mux.Vars(r)
+		code := vars["code"]
+		if code == "" {
+			code = vars["sessionCode"]
+		}
+
+		if code != "" {
+			if ok, wait := l.failedLookups.Check(ip); !ok {
+				tooManyRequests(w, r, wait)
+				return
+			}
+		}
+
+		if ok, wait := l.ip.Allow(ip); !ok {
+			tooManyRequests(w, r, wait)
+			return
+		}
+
+		if r.Method == http.MethodPost && isRoute(r, "/sessions") {
+			if ok, wait := l.creation.Allow(ip); !ok {
+				tooManyRequests(w, r, wait)
+				return
+			}
+		}
+
+		if token := bearerToken(r); token != "" {
+			if claims, err := l.tokenService.Verify(token); err == nil {
+				if ok, wait := l.user.Allow(claims.UserID); !ok {
+					tooManyRequests(w, r, wait)
+					return
+				}
+				// Só quem tem token da sessão gasta o bucket dela; senão qualquer
+				// um que conheça o código poderia esgotá-lo
+				if code != "" && claims.SessionCode == code {
+					if ok, wait := l.session.Allow(code); !ok {
+						tooManyRequests(w, r, wait)
+						return
+					}
+				}
+			}
+		}
+
+		if code == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &notFoundRecorder{statusRecorder: &statusRecorder{ResponseWriter: w, status: http.StatusOK}}
+		next.ServeHTTP(recorder, r)
+		// CARD_NOT_FOUND e USER_NOT_FOUND vêm de quem já conhece a sessão
+		if recorder.code == apperror.CodeSessionNotFound {
+			l.failedLookups.Allow(ip)
+		}
+	})
+}
+
+// Stats retorna os contadores de cada limitador
+func (l *RateLimiter) Stats() map[string]ratelimit.Stats {
+	return map[string]ratelimit.Stats{
+		"ip":            l.ip.Stats(),
+		"user":          l.user.Stats(),
+		"session":       l.session.Stats(),
+		"sessionCreate": l.creation.Stats(),
+		"failedLookups": l.failedLookups.Stats(),
+	}
+}
+
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.limits.TrustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isRoute compara o template da rota, sem APIPrefix, com template
+func isRoute(r *http.Request, template string) bool {
+	return routeTemplate(r) == template
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	respondWithError(w, r, apperror.New(apperror.CodeRateLimited))
+}
+
+// statusRecorder guarda o status da resposta. Implementa Hijacker para não
+// quebrar o upgrade de conexões WebSocket.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	// O upgrade do WebSocket escreve o 101 direto na conexão sequestrada
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// notFoundRecorder guarda o código de erro das respostas 404
+type notFoundRecorder struct {
+	*statusRecorder
+	code apperror.Code
+}
+
+func (r *notFoundRecorder) Write(p []byte) (int, error) {
+	if r.status == http.StatusNotFound && r.code == "" {
+		var response apperror.Response
+		if json.Unmarshal(p, &response) == nil {
+			r.code = response.Code
+		}
+	}
+	return r.statusRecorder.Write(p)
+}
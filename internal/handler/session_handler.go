@@ -13,12 +13,14 @@ import (
 type SessionHandler struct {
 	service           *service.SessionService
 	websocketService  *service.WebsocketService
+	tokenService      *service.TokenService
 }
 
-func NewSessionHandler(service *service.SessionService, websocketService *service.WebsocketService) *SessionHandler {
+func NewSessionHandler(service *service.SessionService, websocketService *service.WebsocketService, tokenService *service.TokenService) *SessionHandler {
 	return &SessionHandler{
 		service:           service,
 		websocketService:  websocketService,
+		tokenService:      tokenService,
 	}
 }
 
@@ -28,23 +30,40 @@ func (h *SessionHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/sessions/{code}", h.GetSessionByCode).Methods("GET")
 	router.HandleFunc("/sessions/{code}/state", h.UpdateSessionState).Methods("PUT")
 	router.HandleFunc("/sessions/{code}/leave", h.LeaveSession).Methods("POST")
+	router.HandleFunc("/sessions/{code}/cards", h.GetSessionCards).Methods("GET")
 	router.HandleFunc("/sessions/{code}/cards", h.CreateCardInSession).Methods("POST")
 	router.HandleFunc("/sessions/{code}/reset-votes", h.ResetSessionVotes).Methods("POST")
+	router.HandleFunc("/sessions/{code}/cards/{id}/vote", h.VoteCard).Methods("POST")
+	router.HandleFunc("/sessions/{code}/cards/{id}/close", h.CloseCardVoting).Methods("POST")
+	router.HandleFunc("/sessions/{code}/users/{userId}", h.KickUser).Methods("DELETE")
+	router.HandleFunc("/sessions/{code}/users/{userId}/role", h.UpdateUserRole).Methods("PUT")
 }
 
 func (h *SessionHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
 	var req domain.CreateSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		respondWithError(w, r, errInvalidPayload)
 		return
 	}
 
 	response, err := h.service.CreateSession(req)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
+	owner := response.Session.GetUser(response.Session.OwnerID)
+	if owner == nil {
+		respondWithError(w, r, errOwnerNotFound)
+		return
+	}
+	response.Token, err = h.tokenService.Issue(*owner, response.Code)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(response.Session.Version))
 	respondWithJSON(w, http.StatusCreated, response)
 }
 
@@ -52,89 +71,96 @@ func (h *SessionHandler) JoinSession(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	var req domain.JoinSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		respondWithError(w, r, errInvalidPayload)
 		return
 	}
 
 	user, err := h.service.JoinSession(params["code"], req)
 	if err != nil {
-		switch err {
-		case service.ErrSessionNotFound:
-			respondWithError(w, http.StatusNotFound, err.Error())
-		case service.ErrSessionClosed:
-			respondWithError(w, http.StatusForbidden, err.Error())
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondWithError(w, r, err)
+		return
+	}
+
+	token, err := h.tokenService.Issue(user, params["code"])
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
 	// Broadcast da atualização para todos os clientes conectados à sessão
 	h.websocketService.BroadcastUserUpdate(params["code"], user, "join")
+	h.broadcastParticipants(params["code"])
 
-	respondWithJSON(w, http.StatusOK, user)
+	respondWithJSON(w, http.StatusOK, domain.JoinSessionResponse{User: user, Token: token})
+}
+
+// broadcastParticipants publica o documento da sessão depois de uma mudança nos
+// participantes: patches e snapshots completos só mudam por ele, e o
+// "user_update" enviado antes é apenas um aviso
+func (h *SessionHandler) broadcastParticipants(code string) {
+	session, err := h.service.GetSessionByCode(code)
+	if err != nil {
+		return
+	}
+	h.websocketService.BroadcastSession(session)
 }
 
 func (h *SessionHandler) UpdateSessionState(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	userID := r.Header.Get("User-ID") // Assumindo que o ID do usuário vem no header
-	if userID == "" {
-		respondWithError(w, http.StatusUnauthorized, "User ID is required")
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
+		return
+	}
+	userID := claims.UserID
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
 
 	var req domain.UpdateSessionStateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		respondWithError(w, r, errInvalidPayload)
 		return
 	}
 
-	err := h.service.UpdateSessionState(params["code"], userID, req)
+	session, err := h.service.UpdateSessionState(params["code"], userID, req, version)
 	if err != nil {
-		switch err {
-		case service.ErrSessionNotFound:
-			respondWithError(w, http.StatusNotFound, err.Error())
-		case service.ErrUnauthorized:
-			respondWithError(w, http.StatusForbidden, err.Error())
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondWithError(w, r, err)
 		return
 	}
 
-	// Obter a sessão atualizada para broadcast
-	session, _ := h.service.GetSessionByCode(params["code"])
 	h.websocketService.BroadcastSession(session)
 
+	w.Header().Set("ETag", etag(session.Version))
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Session state updated successfully"})
 }
 
 func (h *SessionHandler) LeaveSession(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	userID := r.Header.Get("User-ID")
-	if userID == "" {
-		respondWithError(w, http.StatusUnauthorized, "User ID is required")
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
 		return
 	}
+	userID := claims.UserID
 
-	// Obter a sessão antes de remover o usuário para ter as informações do usuário
-	session, _ := h.service.GetSessionByCode(params["code"])
-	user := session.GetUser(userID)
-
-	err := h.service.LeaveSession(params["code"], userID)
+	user, err := h.service.LeaveSession(params["code"], userID)
 	if err != nil {
-		switch err {
-		case service.ErrSessionNotFound:
-			respondWithError(w, http.StatusNotFound, err.Error())
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondWithError(w, r, err)
 		return
 	}
 
+	h.tokenService.RevokeUser(userID)
+	h.websocketService.DisconnectUser(params["code"], userID)
+
 	// Broadcast da atualização para todos os clientes conectados à sessão
-	if user != nil {
-		h.websocketService.BroadcastUserUpdate(params["code"], *user, "leave")
+	if user.ID != "" {
+		h.websocketService.BroadcastUserUpdate(params["code"], user, "leave")
+	}
+	h.broadcastParticipants(params["code"])
+
+	// A saída do owner fecha a sessão, e com ela o hub
+	if user.Role == domain.UserRoleOwner {
+		h.websocketService.RemoveHub(params["code"])
 	}
 
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Left session successfully"})
@@ -142,41 +168,56 @@ func (h *SessionHandler) LeaveSession(w http.ResponseWriter, r *http.Request) {
 
 func (h *SessionHandler) GetSessionByCode(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
+	if _, ok := authenticate(w, r, h.tokenService, params["code"]); !ok {
+		return
+	}
+
 	session, err := h.service.GetSessionByCode(params["code"])
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(session.Version))
 	respondWithJSON(w, http.StatusOK, session)
 }
 
+func (h *SessionHandler) GetSessionCards(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	if _, ok := authenticate(w, r, h.tokenService, params["code"]); !ok {
+		return
+	}
+
+	cards, err := h.service.GetSessionCards(params["code"])
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cards)
+}
+
 func (h *SessionHandler) CreateCardInSession(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	userID := r.Header.Get("User-ID")
-	if userID == "" {
-		respondWithError(w, http.StatusUnauthorized, "User ID is required")
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
+	userID := claims.UserID
 
-	var card domain.Card
-	if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	var req domain.CreateCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, errInvalidPayload)
 		return
 	}
 
-	card, err := h.service.CreateCardInSession(params["code"], userID, card)
+	card, err := h.service.CreateCardInSession(params["code"], userID, req, version)
 	if err != nil {
-		switch err {
-		case service.ErrSessionNotFound:
-			respondWithError(w, http.StatusNotFound, err.Error())
-		case service.ErrUnauthorized:
-			respondWithError(w, http.StatusForbidden, err.Error())
-		case service.ErrSessionClosed:
-			respondWithError(w, http.StatusForbidden, err.Error())
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -188,16 +229,19 @@ func (h *SessionHandler) CreateCardInSession(w http.ResponseWriter, r *http.Requ
 
 func (h *SessionHandler) ResetSessionVotes(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	sessionCode := params["sessionCode"]
-	
-	cards, err := h.service.ResetSessionVotes(sessionCode)
+	sessionCode := params["code"]
+	claims, ok := authenticate(w, r, h.tokenService, sessionCode)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	cards, err := h.service.ResetSessionVotes(sessionCode, claims.UserID, version)
 	if err != nil {
-		switch err {
-		case service.ErrSessionNotFound:
-			respondWithError(w, http.StatusNotFound, err.Error())
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -208,3 +252,112 @@ func (h *SessionHandler) ResetSessionVotes(w http.ResponseWriter, r *http.Reques
 
 	respondWithJSON(w, http.StatusOK, cards)
 }
+
+func (h *SessionHandler) KickUser(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.KickUser(params["code"], claims.UserID, params["userId"], version)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.tokenService.RevokeUser(user.ID)
+	h.websocketService.DisconnectUser(params["code"], user.ID)
+
+	// Broadcast da atualização para todos os clientes conectados à sessão
+	h.websocketService.BroadcastUserUpdate(params["code"], user, "kick")
+	h.broadcastParticipants(params["code"])
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "User removed from session"})
+}
+
+func (h *SessionHandler) VoteCard(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var vote domain.Vote
+	if err := json.NewDecoder(r.Body).Decode(&vote); err != nil {
+		respondWithError(w, r, errInvalidPayload)
+		return
+	}
+
+	card, err := h.service.VoteCard(params["code"], claims.UserID, params["id"], vote, version)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	// Broadcast da atualização para todos os clientes conectados à sessão
+	h.websocketService.BroadcastCard(params["code"], card)
+
+	respondWithJSON(w, http.StatusOK, card)
+}
+
+func (h *SessionHandler) CloseCardVoting(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	card, err := h.service.CloseCardVoting(params["code"], claims.UserID, params["id"], version)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	// Broadcast da atualização para todos os clientes conectados à sessão
+	h.websocketService.BroadcastCard(params["code"], card)
+
+	respondWithJSON(w, http.StatusOK, card)
+}
+
+func (h *SessionHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	claims, ok := authenticate(w, r, h.tokenService, params["code"])
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, errInvalidPayload)
+		return
+	}
+
+	user, err := h.service.UpdateUserRole(params["code"], claims.UserID, params["userId"], req.Role, version)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	// Broadcast da atualização para todos os clientes conectados à sessão
+	h.websocketService.BroadcastUserUpdate(params["code"], user, "role")
+	h.broadcastParticipants(params["code"])
+
+	respondWithJSON(w, http.StatusOK, user)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"flash-cards/backend/internal/service"
+)
+
+// ShutdownGate recusa, durante o desligamento, as requisições que criariam
+// estado novo: criação de sessões, entrada em sessões e conexões WebSocket.
+// As demais continuam sendo atendidas até o servidor HTTP parar.
+type ShutdownGate struct {
+	draining   atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewShutdownGate cria o gate; retryAfter é sugerido aos clientes recusados
+func NewShutdownGate(retryAfter time.Duration) *ShutdownGate {
+	return &ShutdownGate{retryAfter: retryAfter}
+}
+
+// Drain passa a recusar novas sessões e conexões
+func (g *ShutdownGate) Drain() {
+	g.draining.Store(true)
+}
+
+// Draining informa se o desligamento já começou
+func (g *ShutdownGate) Draining() bool {
+	return g.draining.Load()
+}
+
+// Middleware deve ser registrado com router.Use, para que a rota esteja disponível
+func (g *ShutdownGate) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.Draining() && createsState(r) {
+			seconds := int(math.Ceil(g.retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			respondWithError(w, r, service.ErrShuttingDown)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func createsState(r *http.Request) bool {
+	return (r.Method == http.MethodPost && isRoute(r, "/sessions")) ||
+		isRoute(r, "/sessions/{code}/join") ||
+		isRoute(r, "/ws/{sessionCode}")
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"flash-cards/backend/internal/service"
@@ -9,28 +10,46 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// websocketRoute é o template da rota de upgrade, a única que aceita o token em ?token=
+const websocketRoute = "/ws/{sessionCode}"
+
 // WebsocketHandler gerencia as conexões WebSocket
 type WebsocketHandler struct {
 	websocketService *service.WebsocketService
+	tokenService     *service.TokenService
 }
 
 // NewWebsocketHandler cria uma nova instância do handler de WebSocket
-func NewWebsocketHandler(websocketService *service.WebsocketService) *WebsocketHandler {
+func NewWebsocketHandler(websocketService *service.WebsocketService, tokenService *service.TokenService) *WebsocketHandler {
 	return &WebsocketHandler{
 		websocketService: websocketService,
+		tokenService:     tokenService,
 	}
 }
 
 // RegisterRoutes registra as rotas do WebSocket
 func (h *WebsocketHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/ws/{sessionCode}", h.HandleWebSocket).Methods("GET")
+	router.HandleFunc(websocketRoute, h.HandleWebSocket).Methods("GET")
 }
 
-// HandleWebSocket gerencia a conexão WebSocket para uma sessão específica
+// HandleWebSocket gerencia a conexão WebSocket para uma sessão específica.
+// O token do participante vem em ?token=, já que navegadores não enviam headers no upgrade.
 func (h *WebsocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionCode := vars["sessionCode"]
-	
-	hub := h.websocketService.GetHub(sessionCode)
-	websocket.ServeWs(hub, w, r)
+
+	claims, ok := authenticate(w, r, h.tokenService, sessionCode)
+	if !ok {
+		return
+	}
+
+	hub, err := h.websocketService.GetHub(sessionCode)
+	if err != nil {
+		if errors.Is(err, service.ErrShuttingDown) {
+			w.Header().Set("Retry-After", "5")
+		}
+		respondWithError(w, r, err)
+		return
+	}
+	websocket.ServeWs(hub, w, r, claims.UserID)
 } 
\ No newline at end of file
@@ -0,0 +1,221 @@
+// Package jsonpatch gera diferenças entre documentos JSON no formato JSON Patch (RFC 6902).
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation é uma operação JSON Patch
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON sempre inclui value em add, replace e test, mesmo quando ele é
+// nulo ou vazio: a RFC 6902 exige o membro nessas operações
+func (o Operation) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{o.Op, o.Path, o.Value})
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+	}{o.Op, o.Path})
+}
+
+// Diff retorna as operações que transformam a em b. Os dois documentos devem
+// estar na forma produzida por encoding/json ao decodificar em interface{}.
+func Diff(a, b interface{}) []Operation {
+	ops := make([]Operation, 0)
+	diff("", a, b, &ops)
+	return ops
+}
+
+func diff(path string, a, b interface{}, ops *[]Operation) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			*ops = append(*ops, Operation{Op: "replace", Path: path, Value: b})
+			return
+		}
+		for _, key := range sortedKeys(av) {
+			child := path + "/" + escape(key)
+			if value, exists := bv[key]; exists {
+				diff(child, av[key], value, ops)
+			} else {
+				*ops = append(*ops, Operation{Op: "remove", Path: child})
+			}
+		}
+		for _, key := range sortedKeys(bv) {
+			if _, exists := av[key]; !exists {
+				*ops = append(*ops, Operation{Op: "add", Path: path + "/" + escape(key), Value: bv[key]})
+			}
+		}
+
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			*ops = append(*ops, Operation{Op: "replace", Path: path, Value: b})
+			return
+		}
+		common := len(av)
+		if len(bv) < common {
+			common = len(bv)
+		}
+		for i := 0; i < common; i++ {
+			diff(path+"/"+strconv.Itoa(i), av[i], bv[i], ops)
+		}
+		for i := common; i < len(bv); i++ {
+			*ops = append(*ops, Operation{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: bv[i]})
+		}
+		// Remover do fim para o início mantém os índices válidos
+		for i := len(av) - 1; i >= common; i-- {
+			*ops = append(*ops, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*ops = append(*ops, Operation{Op: "replace", Path: path, Value: b})
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escape codifica um segmento de JSON Pointer (RFC 6901)
+func escape(segment string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+}
+
+// Apply aplica as operações add, remove e replace produzidas por Diff a uma
+// cópia de doc, que deve estar na forma produzida por encoding/json ao
+// decodificar em interface{}
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	result := deepCopy(doc)
+	for _, op := range ops {
+		var err error
+		result, err = apply(result, split(op.Path), op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	return result, nil
+}
+
+func apply(node interface{}, segments []string, op Operation) (interface{}, error) {
+	if len(segments) == 0 {
+		switch op.Op {
+		case "add", "replace":
+			return deepCopy(op.Value), nil
+		}
+		return nil, fmt.Errorf("unsupported operation on the document root")
+	}
+	key, rest := segments[0], segments[1:]
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		child, exists := container[key]
+		if len(rest) > 0 {
+			if !exists {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			updated, err := apply(child, rest, op)
+			container[key] = updated
+			return container, err
+		}
+		switch op.Op {
+		case "add":
+			container[key] = deepCopy(op.Value)
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			container[key] = deepCopy(op.Value)
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			delete(container, key)
+		default:
+			return nil, fmt.Errorf("unsupported operation")
+		}
+		return container, nil
+
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if key == "-" && op.Op == "add" && len(rest) == 0 {
+			index, err = len(container), nil
+		}
+		if err != nil || index < 0 || index > len(container) || (index == len(container) && (op.Op != "add" || len(rest) > 0)) {
+			return nil, fmt.Errorf("index %q out of range", key)
+		}
+		if len(rest) > 0 {
+			updated, err := apply(container[index], rest, op)
+			container[index] = updated
+			return container, err
+		}
+		switch op.Op {
+		case "add":
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = deepCopy(op.Value)
+		case "replace":
+			container[index] = deepCopy(op.Value)
+		case "remove":
+			container = append(container[:index], container[index+1:]...)
+		default:
+			return nil, fmt.Errorf("unsupported operation")
+		}
+		return container, nil
+	}
+	return nil, fmt.Errorf("%q is not a container", key)
+}
+
+// split decodifica um JSON Pointer em segmentos
+func split(path string) []string {
+	if path == "" {
+		return nil
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	}
+	return segments
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[key] = deepCopy(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, child := range v {
+			result[i] = deepCopy(child)
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, raw string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		t.Fatalf("decoding %s: %v", raw, err)
+	}
+	return value
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2]}`, `[]`},
+		{"replace scalar", `{"a":1}`, `{"a":2}`, `[{"op":"replace","path":"/a","value":2}]`},
+		{"add and remove members", `{"a":1,"b":2}`, `{"b":2,"c":3}`,
+			`[{"op":"remove","path":"/a"},{"op":"add","path":"/c","value":3}]`},
+		{"append to array", `{"l":[1]}`, `{"l":[1,2,3]}`,
+			`[{"op":"add","path":"/l/1","value":2},{"op":"add","path":"/l/2","value":3}]`},
+		{"shrink array from the end", `{"l":[1,2,3]}`, `{"l":[1]}`,
+			`[{"op":"remove","path":"/l/2"},{"op":"remove","path":"/l/1"}]`},
+		{"nested change", `{"u":[{"name":"Ana","role":"GUEST"}]}`, `{"u":[{"name":"Ana","role":"OWNER"}]}`,
+			`[{"op":"replace","path":"/u/0/role","value":"OWNER"}]`},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1]}`, `[{"op":"replace","path":"/a","value":[1]}]`},
+		{"escaped keys", `{}`, `{"a/b":1,"c~d":2}`,
+			`[{"op":"add","path":"/a~1b","value":1},{"op":"add","path":"/c~0d","value":2}]`},
+		{"null value", `{"a":1}`, `{"a":null}`, `[{"op":"replace","path":"/a","value":null}]`},
+		{"empty value", `{}`, `{"a":""}`, `[{"op":"add","path":"/a","value":""}]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := decode(t, test.a), decode(t, test.b)
+			ops := Diff(a, b)
+
+			raw, err := json.Marshal(ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decode(t, string(raw)), decode(t, test.want)) {
+				t.Fatalf("Diff = %s, want %s", raw, test.want)
+			}
+
+			patched, err := Apply(a, ops)
+			if err != nil {
+				t.Fatalf("applying %s: %v", raw, err)
+			}
+			if !reflect.DeepEqual(patched, b) {
+				t.Fatalf("Apply(a, Diff(a, b)) = %v, want %v", patched, b)
+			}
+			if !reflect.DeepEqual(a, decode(t, test.a)) {
+				t.Fatalf("Apply changed its input: %v", a)
+			}
+		})
+	}
+}
+
+func TestOperationMarshalJSON(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		want string
+	}{
+		{Operation{Op: "add", Path: "/a"}, `{"op":"add","path":"/a","value":null}`},
+		{Operation{Op: "replace", Path: "/a", Value: ""}, `{"op":"replace","path":"/a","value":""}`},
+		{Operation{Op: "test", Path: "/a", Value: 0}, `{"op":"test","path":"/a","value":0}`},
+		{Operation{Op: "remove", Path: "/a"}, `{"op":"remove","path":"/a"}`},
+	}
+	for _, test := range tests {
+		raw, err := json.Marshal(test.op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != test.want {
+			t.Fatalf("Marshal(%+v) = %s, want %s", test.op, raw, test.want)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		op   Operation
+	}{
+		{"replace missing member", Operation{Op: "replace", Path: "/missing", Value: 1}},
+		{"remove missing member", Operation{Op: "remove", Path: "/missing"}},
+		{"index out of range", Operation{Op: "replace", Path: "/l/5", Value: 1}},
+		{"path through a scalar", Operation{Op: "add", Path: "/a/b", Value: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Apply(decode(t, `{"a":1,"l":[1]}`), []Operation{test.op}); err == nil {
+				t.Fatalf("expected an error for %+v", test.op)
+			}
+		})
+	}
+}
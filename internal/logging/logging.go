@@ -0,0 +1,119 @@
+// Package logging configura o log estruturado (log/slog) e carrega, no contexto
+// da requisição, os campos de correlação: request ID, código da sessão e usuário.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Nomes dos campos de correlação, usados em todo o servidor
+const (
+	KeyRequestID = "request_id"
+	KeySession   = "session"
+	KeyUserID    = "user_id"
+	KeyClientID  = "client_id"
+)
+
+// Options define o formato e o nível do log
+type Options struct {
+	// Level é debug, info, warn ou error
+	Level string `json:"level"`
+	// Format é json ou text
+	Format string `json:"format"`
+}
+
+// DefaultOptions retorna JSON no nível info
+func DefaultOptions() Options {
+	return Options{Level: "info", Format: "json"}
+}
+
+// ParseLevel converte o nome de um nível, retornando erro se for desconhecido
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// Validate confere o nível e o formato
+func (o Options) Validate() error {
+	if _, err := ParseLevel(o.Level); err != nil {
+		return err
+	}
+	switch strings.ToLower(o.Format) {
+	case "json", "text":
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q", o.Format)
+	}
+}
+
+// New cria o logger que escreve em out com as opções informadas
+func New(out io.Writer, options Options) (*slog.Logger, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	level, _ := ParseLevel(options.Level)
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	if strings.ToLower(options.Format) == "text" {
+		return slog.New(slog.NewTextHandler(out, handlerOptions)), nil
+	}
+	return slog.New(slog.NewJSONHandler(out, handlerOptions)), nil
+}
+
+type contextKey struct{}
+
+// fields são os campos de correlação de uma requisição. É um ponteiro guardado
+// no contexto para que quem descobre um campo depois (a autenticação descobre
+// o usuário) o acrescente sem precisar trocar o contexto da requisição.
+type fields struct {
+	logger *slog.Logger
+	attrs  []any
+	mutex  sync.Mutex
+}
+
+// NewContext retorna um contexto que carrega o logger e os campos informados
+func NewContext(ctx context.Context, logger *slog.Logger, attrs ...any) context.Context {
+	return context.WithValue(ctx, contextKey{}, &fields{logger: logger, attrs: attrs})
+}
+
+// AddFields acrescenta campos de correlação ao contexto criado por NewContext.
+// Sem ele, não faz nada.
+func AddFields(ctx context.Context, attrs ...any) {
+	if f, ok := ctx.Value(contextKey{}).(*fields); ok {
+		f.mutex.Lock()
+		f.attrs = append(f.attrs, attrs...)
+		f.mutex.Unlock()
+	}
+}
+
+// FromContext retorna o logger do contexto com todos os campos de correlação
+// acrescentados até o momento, ou slog.Default() se não houver um
+func FromContext(ctx context.Context) *slog.Logger {
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	if !ok {
+		return slog.Default()
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.logger.With(f.attrs...)
+}
+
+// PrintfLogger adapta o logger a bibliotecas que esperam Printf, como o CORS,
+// registrando as mensagens no nível informado
+type PrintfLogger struct {
+	Logger *slog.Logger
+	Level  slog.Level
+}
+
+func (p PrintfLogger) Printf(format string, args ...interface{}) {
+	p.Logger.Log(context.Background(), p.Level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,332 @@
+// Package metrics implementa o mínimo do formato de exposição de texto do
+// Prometheus (versão 0.0.4) sobre a biblioteca padrão: contadores e
+// histogramas com labels, e métricas calculadas no momento da coleta.
+//
+// /metrics não exige autenticação: labels nunca devem carregar códigos de
+// sessão, IDs ou outros valores que deem acesso a algo ou cresçam sem limite.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tipos de métrica do formato de exposição
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets são os limites, em segundos, usados para latência de requisições
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample é um valor de uma métrica calculada, com os valores dos labels na
+// ordem em que foram declarados
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collector escreve as linhas de uma família de métricas
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry guarda as métricas registradas e as expõe em /metrics
+type Registry struct {
+	collectors []collector
+	names      map[string]bool
+	mutex      sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// Counter registra um contador com os labels informados
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: family{name: name, help: help, labels: labels}, values: make(map[string]*counterValue)}
+	r.register(name, c)
+	return c
+}
+
+// Histogram registra um histograma com os limites (em ordem crescente) e labels informados
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{family: family{name: name, help: help, labels: labels}, buckets: buckets, values: make(map[string]*histogramValue)}
+	r.register(name, h)
+	return h
+}
+
+// GaugeFunc registra um gauge cujos valores são calculados por collect a cada coleta
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(name, &funcCollector{family: family{name: name, help: help, labels: labels}, kind: TypeGauge, collect: collect})
+}
+
+// CounterFunc registra um contador mantido fora do registry (por exemplo, um
+// atomic.Uint64), lido por collect a cada coleta
+func (r *Registry) CounterFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(name, &funcCollector{family: family{name: name, help: help, labels: labels}, kind: TypeCounter, collect: collect})
+}
+
+// HistogramFunc registra um histograma sem labels calculado a cada coleta:
+// collect retorna todos os valores observados naquele momento
+func (r *Registry) HistogramFunc(name, help string, buckets []float64, collect func() []float64) {
+	r.register(name, &funcHistogram{family: family{name: name, help: help}, buckets: buckets, collect: collect})
+}
+
+// Write escreve todas as métricas no formato de texto
+func (r *Registry) Write(out io.Writer) error {
+	r.mutex.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.Unlock()
+
+	w := bufio.NewWriter(out)
+	for _, c := range collectors {
+		c.write(w)
+	}
+	return w.Flush()
+}
+
+// Handler expõe as métricas para o Prometheus
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// family são os dados comuns a todas as séries de uma métrica
+type family struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (f family) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, kind)
+}
+
+func (f family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// Counter é um contador com labels
+type Counter struct {
+	family
+	values map[string]*counterValue
+	mutex  sync.Mutex
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// Inc incrementa a série com os valores de label informados
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add soma delta (não negativo) à série com os valores de label informados
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	value, exists := c.values[key]
+	if !exists {
+		value = &counterValue{labels: append([]string(nil), labelValues...)}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.header(w, TypeCounter)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		writeSample(w, c.name, c.labels, value.labels, "", "", value.value)
+	}
+}
+
+// Histogram é um histograma cumulativo com labels
+type Histogram struct {
+	family
+	buckets []float64
+	values  map[string]*histogramValue
+	mutex   sync.Mutex
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Observe registra uma medida na série com os valores de label informados
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	series, exists := h.values[key]
+	if !exists {
+		series = &histogramValue{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.header(w, TypeHistogram)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, key := range sortedKeys(h.values) {
+		series := h.values[key]
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, series.labels, "le", formatFloat(bound), float64(series.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, series.labels, "le", "+Inf", float64(series.count))
+		writeSample(w, h.name+"_sum", h.labels, series.labels, "", "", series.sum)
+		writeSample(w, h.name+"_count", h.labels, series.labels, "", "", float64(series.count))
+	}
+}
+
+type funcCollector struct {
+	family
+	kind    string
+	collect func() []Sample
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	f.header(w, f.kind)
+
+	samples := f.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	for _, sample := range samples {
+		f.key(sample.LabelValues)
+		writeSample(w, f.name, f.labels, sample.LabelValues, "", "", sample.Value)
+	}
+}
+
+type funcHistogram struct {
+	family
+	buckets []float64
+	collect func() []float64
+}
+
+func (f *funcHistogram) write(w *bufio.Writer) {
+	f.header(w, TypeHistogram)
+
+	values := f.collect()
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	for _, bound := range f.buckets {
+		var count int
+		for _, value := range values {
+			if value <= bound {
+				count++
+			}
+		}
+		writeSample(w, f.name+"_bucket", nil, nil, "le", formatFloat(bound), float64(count))
+	}
+	writeSample(w, f.name+"_bucket", nil, nil, "le", "+Inf", float64(len(values)))
+	writeSample(w, f.name+"_sum", nil, nil, "", "", sum)
+	writeSample(w, f.name+"_count", nil, nil, "", "", float64(len(values)))
+}
+
+// writeSample escreve uma linha "nome{labels} valor". extraName e extraValue
+// acrescentam um label fixo, como o "le" dos histogramas.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string { return labelEscaper.Replace(value) }
+func escapeHelp(value string) string  { return helpEscaper.Replace(value) }
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
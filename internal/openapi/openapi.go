@@ -0,0 +1,119 @@
+// Package openapi guarda o contrato da API (um documento OpenAPI 3, embutido no
+// binário) e valida os corpos JSON das requisições contra ele.
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// Document retorna o documento OpenAPI da API
+func Document() []byte {
+	return document
+}
+
+// Handler serve o documento OpenAPI
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	})
+}
+
+// spec é a parte do documento usada na validação
+type spec struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *Schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// Validator confere os corpos das requisições contra os schemas do documento
+type Validator struct {
+	schemas resolver
+	// bodies indexa o schema do corpo por "MÉTODO /caminho", com o caminho na
+	// forma do documento, como "POST /sessions/{code}/join"
+	bodies map[string]*Schema
+}
+
+// NewValidator carrega o documento embutido e compila os schemas
+func NewValidator() (*Validator, error) {
+	var parsed spec
+	if err := json.Unmarshal(document, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI document: %w", err)
+	}
+
+	v := &Validator{schemas: resolver(parsed.Components.Schemas), bodies: make(map[string]*Schema)}
+	seen := make(map[*Schema]bool)
+	for name, schema := range parsed.Components.Schemas {
+		if err := v.schemas.compile(schema, seen); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	for path, operations := range parsed.Paths {
+		for method, op := range operations {
+			if op.RequestBody == nil {
+				continue
+			}
+			media, ok := op.RequestBody.Content["application/json"]
+			if !ok || media.Schema == nil {
+				continue
+			}
+			if err := v.schemas.compile(media.Schema, seen); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			v.bodies[strings.ToUpper(method)+" "+path] = media.Schema
+		}
+	}
+	return v, nil
+}
+
+// HasBody informa se a operação declara um corpo JSON
+func (v *Validator) HasBody(method, path string) bool {
+	_, ok := v.bodies[method+" "+path]
+	return ok
+}
+
+// ValidateBody confere o corpo da operação e retorna um erro por campo
+// inválido. Operações sem corpo declarado aceitam qualquer conteúdo.
+func (v *Validator) ValidateBody(method, path string, data []byte) []FieldError {
+	schema, ok := v.bodies[method+" "+path]
+	if !ok {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return []FieldError{{Message: "request body is required"}}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []FieldError{{Message: "invalid JSON: " + err.Error()}}
+	}
+	if decoder.More() {
+		return []FieldError{{Message: "invalid JSON: unexpected data after the top-level value"}}
+	}
+
+	var errs []FieldError
+	v.schemas.validate(schema, value, "", &errs)
+	return errs
+}
@@ -0,0 +1,104 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateBody(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   []FieldError
+	}{
+		{"valid session", "POST", "/sessions", `{"ownerName":"Ana"}`, nil},
+		{"missing ownerName", "POST", "/sessions", `{}`,
+			[]FieldError{{Field: "ownerName", Message: "is required"}}},
+		{"missing userName", "POST", "/sessions/{code}/join", `{"code":"ABCD12"}`,
+			[]FieldError{{Field: "userName", Message: "is required"}}},
+		{"missing state", "PUT", "/sessions/{code}/state", `{}`,
+			[]FieldError{{Field: "state", Message: "is required"}}},
+		{"missing score", "POST", "/sessions/{code}/cards/{id}/vote", `{}`,
+			[]FieldError{{Field: "score", Message: "is required"}}},
+		{"ownerName is not a string", "POST", "/sessions", `{"ownerName":42}`,
+			[]FieldError{{Field: "ownerName", Message: "must be a string, got number"}}},
+		{"score is not an integer", "POST", "/sessions/{code}/cards/{id}/vote", `{"score":1.5}`,
+			[]FieldError{{Field: "score", Message: "must be an integer"}}},
+		{"score is a string", "POST", "/sessions/{code}/cards/{id}/vote", `{"score":"5"}`,
+			[]FieldError{{Field: "score", Message: "must be an integer, got string"}}},
+		{"score below the minimum", "POST", "/sessions/{code}/cards/{id}/vote", `{"score":-1}`,
+			[]FieldError{{Field: "score", Message: "must be at least 0"}}},
+		{"null ownerName", "POST", "/sessions", `{"ownerName":null}`,
+			[]FieldError{{Field: "ownerName", Message: "must be a string, got null"}}},
+		{"body is not an object", "POST", "/sessions", `["Ana"]`,
+			[]FieldError{{Message: "must be an object, got array"}}},
+		{"unknown state", "PUT", "/sessions/{code}/state", `{"state":"PAUSED"}`,
+			[]FieldError{{Field: "state", Message: "must be one of OPEN, CLOSED"}}},
+		{"owner role is not assignable", "PUT", "/sessions/{code}/users/{userId}/role", `{"role":"OWNER"}`,
+			[]FieldError{{Field: "role", Message: "must be one of FACILITATOR, GUEST"}}},
+		{"ownerName at maxLength", "POST", "/sessions", `{"ownerName":"` + strings.Repeat("a", 40) + `"}`, nil},
+		{"ownerName over maxLength", "POST", "/sessions", `{"ownerName":"` + strings.Repeat("a", 41) + `"}`,
+			[]FieldError{{Field: "ownerName", Message: "must have at most 40 characters"}}},
+		{"maxLength counts characters, not bytes", "POST", "/sessions", `{"ownerName":"` + strings.Repeat("é", 40) + `"}`, nil},
+		{"title over maxLength", "POST", "/sessions/{code}/cards", `{"title":"` + strings.Repeat("a", 201) + `"}`,
+			[]FieldError{{Field: "title", Message: "must have at most 200 characters"}}},
+		{"empty title", "POST", "/sessions/{code}/cards", `{"title":""}`,
+			[]FieldError{{Field: "title", Message: "must have at least 1 characters"}}},
+		{"every problem is reported", "POST", "/sessions/{code}/cards", `{"title":7,"description":false}`,
+			[]FieldError{
+				{Field: "description", Message: "must be a string, got boolean"},
+				{Field: "title", Message: "must be a string, got number"},
+			}},
+		{"empty body", "POST", "/sessions", "  ",
+			[]FieldError{{Message: "request body is required"}}},
+		{"invalid JSON", "POST", "/sessions", `{"ownerName":`,
+			[]FieldError{{Message: "invalid JSON: unexpected EOF"}}},
+		{"data after the value", "POST", "/sessions", `{"ownerName":"Ana"} {}`,
+			[]FieldError{{Message: "invalid JSON: unexpected data after the top-level value"}}},
+		{"unknown path", "POST", "/sessions/{code}/unknown", `not json`, nil},
+		{"unknown method", "DELETE", "/sessions", `not json`, nil},
+		{"operation without a body", "POST", "/sessions/{code}/leave", `not json`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := validator.ValidateBody(test.method, test.path, []byte(test.body))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ValidateBody(%s %s, %s) = %v, want %v", test.method, test.path, test.body, got, test.want)
+			}
+		})
+	}
+}
+
+func TestHasBody(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{"POST", "/sessions", true},
+		{"PUT", "/sessions/{code}/users/{userId}/role", true},
+		{"GET", "/sessions/{code}", false},
+		{"DELETE", "/sessions", false},
+		{"POST", "/sessions/{code}/unknown", false},
+		// Os métodos do documento são comparados em maiúsculas
+		{"post", "/sessions", false},
+	}
+	for _, test := range tests {
+		if got := validator.HasBody(test.method, test.path); got != test.want {
+			t.Errorf("HasBody(%s %s) = %v, want %v", test.method, test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema é o subconjunto do Schema Object do OpenAPI 3.0 usado pelo documento
+// da API. Palavras-chave fora dele são aceitas no documento, mas ignoradas na
+// validação.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Additional        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Additional é o valor de additionalProperties: um booleano ou o schema que
+// os campos não declarados devem seguir
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+func (a Additional) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// FieldError descreve um campo que não respeita o schema. Field usa a notação
+// de ponto e colchetes, como "result.distribution" ou "votes[2]"; vazio
+// indica o corpo inteiro.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// resolver encontra os schemas referenciados por "#/components/schemas/Nome"
+type resolver map[string]*Schema
+
+const schemaRefPrefix = "#/components/schemas/"
+
+func (r resolver) resolve(schema *Schema) (*Schema, error) {
+	for depth := 0; schema.Ref != ""; depth++ {
+		if depth > 16 {
+			return nil, fmt.Errorf("reference cycle at %s", schema.Ref)
+		}
+		name := strings.TrimPrefix(schema.Ref, schemaRefPrefix)
+		target, ok := r[name]
+		if !ok || name == schema.Ref {
+			return nil, fmt.Errorf("unknown reference %s", schema.Ref)
+		}
+		schema = target
+	}
+	return schema, nil
+}
+
+// compile resolve as referências e compila os patterns, para que erros no
+// documento apareçam na inicialização e não na primeira requisição
+func (r resolver) compile(schema *Schema, seen map[*Schema]bool) error {
+	if schema == nil || seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+
+	if schema.Ref != "" {
+		target, err := r.resolve(schema)
+		if err != nil {
+			return err
+		}
+		return r.compile(target, seen)
+	}
+	if schema.Pattern != "" {
+		pattern, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", schema.Pattern, err)
+		}
+		schema.pattern = pattern
+	}
+	for _, property := range schema.Properties {
+		if err := r.compile(property, seen); err != nil {
+			return err
+		}
+	}
+	for _, part := range schema.AllOf {
+		if err := r.compile(part, seen); err != nil {
+			return err
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		if err := r.compile(schema.AdditionalProperties.Schema, seen); err != nil {
+			return err
+		}
+	}
+	return r.compile(schema.Items, seen)
+}
+
+// validate confere value, decodificado com UseNumber, contra schema e acumula
+// os erros encontrados em errs
+func (r resolver) validate(schema *Schema, value interface{}, field string, errs *[]FieldError) {
+	schema, err := r.resolve(schema)
+	if err != nil {
+		*errs = append(*errs, FieldError{Field: field, Message: err.Error()})
+		return
+	}
+	report := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, part := range schema.AllOf {
+		r.validate(part, value, field, errs)
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			report("must be %s, got null", article(schema.Type))
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			report("must be an object, got %s", jsonType(value))
+			return
+		}
+		for _, name := range schema.Required {
+			if _, present := object[name]; !present {
+				*errs = append(*errs, FieldError{Field: join(field, name), Message: "is required"})
+			}
+		}
+		for _, name := range sortedNames(object) {
+			property, known := schema.Properties[name]
+			additional := schema.AdditionalProperties
+			switch {
+			case known:
+				r.validate(property, object[name], join(field, name), errs)
+			case additional == nil:
+			case additional.Schema != nil:
+				r.validate(additional.Schema, object[name], join(field, name), errs)
+			case !additional.Allowed:
+				*errs = append(*errs, FieldError{Field: join(field, name), Message: "is not a known field"})
+			}
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			report("must be an array, got %s", jsonType(value))
+			return
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			report("must have at most %d items", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				r.validate(schema.Items, item, field+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			report("must be a string, got %s", jsonType(value))
+			return
+		}
+		length := utf8.RuneCountInString(text)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			report("must have at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			report("must have at most %d characters", *schema.MaxLength)
+		}
+		if schema.pattern != nil && !schema.pattern.MatchString(text) {
+			report("must match %s", schema.Pattern)
+		}
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			report("must be %s, got %s", article(schema.Type), jsonType(value))
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				report("must be an integer")
+				return
+			}
+		}
+		parsed, err := number.Float64()
+		if err != nil {
+			report("must be a number")
+			return
+		}
+		if schema.Minimum != nil && parsed < *schema.Minimum {
+			report("must be at least %s", strconv.FormatFloat(*schema.Minimum, 'g', -1, 64))
+		}
+		if schema.Maximum != nil && parsed > *schema.Maximum {
+			report("must be at most %s", strconv.FormatFloat(*schema.Maximum, 'g', -1, 64))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("must be a boolean, got %s", jsonType(value))
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		options := make([]string, len(schema.Enum))
+		for i, option := range schema.Enum {
+			options[i] = fmt.Sprint(option)
+		}
+		report("must be one of %s", strings.Join(options, ", "))
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func sortedNames(object map[string]interface{}) []string {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+func article(kind string) string {
+	switch kind {
+	case "object", "array", "integer":
+		return "an " + kind
+	default:
+		return "a " + kind
+	}
+}
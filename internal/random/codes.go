@@ -0,0 +1,137 @@
+package random
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Formatos de código de sessão
+const (
+	// CodeFormatCharset gera códigos com caracteres sorteados de um alfabeto, ex.: "K7XQ2M"
+	CodeFormatCharset = "charset"
+	// CodeFormatWords gera códigos legíveis no formato adjetivo-animal-número, ex.: "brave-otter-42"
+	CodeFormatWords = "words"
+)
+
+// DefaultAlphabet omite caracteres que se confundem ao serem lidos ou ditados (O/0, I/1)
+const DefaultAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// CodeOptions configura o gerador de códigos de sessão
+type CodeOptions struct {
+	Format string `json:"format"`
+	// Length e Alphabet valem para CodeFormatCharset
+	Length   int    `json:"length"`
+	Alphabet string `json:"alphabet"`
+	// Digits é a quantidade de dígitos ao final de um código CodeFormatWords
+	Digits int `json:"digits"`
+}
+
+// DefaultCodeOptions retorna as opções padrão: 6 caracteres do DefaultAlphabet
+func DefaultCodeOptions() CodeOptions {
+	return CodeOptions{
+		Format:   CodeFormatCharset,
+		Length:   6,
+		Alphabet: DefaultAlphabet,
+		Digits:   2,
+	}
+}
+
+// CodeGenerator gera um novo código candidato a cada chamada; a unicidade é
+// responsabilidade de quem guarda os códigos
+type CodeGenerator interface {
+	Generate() string
+	// Space é a quantidade de códigos distintos que o gerador pode produzir
+	Space() float64
+}
+
+// NewCodeGenerator valida as opções e cria o gerador correspondente, que sorteia
+// os caracteres com source
+func NewCodeGenerator(options CodeOptions, source Source) (CodeGenerator, error) {
+	switch options.Format {
+	case "", CodeFormatCharset:
+		if options.Length < 4 {
+			return nil, fmt.Errorf("code length must be at least 4, got %d", options.Length)
+		}
+		if len(options.Alphabet) < 10 {
+			return nil, fmt.Errorf("code alphabet must have at least 10 characters, got %d", len(options.Alphabet))
+		}
+		for _, char := range options.Alphabet {
+			if char > 127 || strings.Count(options.Alphabet, string(char)) > 1 {
+				return nil, fmt.Errorf("code alphabet must have distinct ASCII characters")
+			}
+		}
+		return charsetCodes{length: options.Length, alphabet: options.Alphabet, source: source}, nil
+	case CodeFormatWords:
+		if options.Digits < 0 || options.Digits > 6 {
+			return nil, fmt.Errorf("code digits must be between 0 and 6, got %d", options.Digits)
+		}
+		return wordCodes{digits: options.Digits, source: source}, nil
+	default:
+		return nil, fmt.Errorf("unknown code format %q", options.Format)
+	}
+}
+
+type charsetCodes struct {
+	length   int
+	alphabet string
+	source   Source
+}
+
+func (g charsetCodes) Generate() string {
+	code := make([]byte, g.length)
+	for i := range code {
+		code[i] = g.alphabet[g.source.Intn(len(g.alphabet))]
+	}
+	return string(code)
+}
+
+func (g charsetCodes) Space() float64 {
+	space := 1.0
+	for i := 0; i < g.length; i++ {
+		space *= float64(len(g.alphabet))
+	}
+	return space
+}
+
+type wordCodes struct {
+	digits int
+	source Source
+}
+
+func (g wordCodes) Generate() string {
+	code := adjectives[g.source.Intn(len(adjectives))] + "-" + animals[g.source.Intn(len(animals))]
+	if g.digits > 0 {
+		code += fmt.Sprintf("-%0*d", g.digits, g.source.Intn(g.pow10()))
+	}
+	return code
+}
+
+func (g wordCodes) Space() float64 {
+	return float64(len(adjectives) * len(animals) * g.pow10())
+}
+
+func (g wordCodes) pow10() int {
+	n := 1
+	for i := 0; i < g.digits; i++ {
+		n *= 10
+	}
+	return n
+}
+
+var adjectives = []string{
+	"agile", "bold", "brave", "bright", "calm", "clever", "cosmic", "crisp",
+	"daring", "eager", "fancy", "fast", "fierce", "gentle", "giant", "glad",
+	"golden", "happy", "humble", "jolly", "keen", "kind", "lively", "lucky",
+	"mellow", "merry", "mighty", "neat", "noble", "proud", "quick", "quiet",
+	"rapid", "rustic", "shiny", "silent", "smart", "snowy", "solid", "sunny",
+	"swift", "tidy", "urban", "vivid", "wise", "witty", "young", "zesty",
+}
+
+var animals = []string{
+	"badger", "bear", "beaver", "bison", "camel", "cobra", "crane", "eagle",
+	"falcon", "ferret", "fox", "gecko", "heron", "hippo", "ibis", "jaguar",
+	"koala", "lemur", "lion", "llama", "lynx", "moose", "newt", "ocelot",
+	"orca", "otter", "owl", "panda", "parrot", "puma", "quail", "rabbit",
+	"raven", "robin", "salmon", "seal", "shark", "sloth", "swan", "tiger",
+	"toucan", "turtle", "viper", "walrus", "whale", "wolf", "yak", "zebra",
+}
@@ -0,0 +1,23 @@
+package random
+
+import "github.com/google/uuid"
+
+// IDGenerator gera os IDs de sessões, participantes, cards, requisições e
+// conexões WebSocket
+type IDGenerator interface {
+	NewID() string
+}
+
+// NewIDGenerator gera UUIDs v4 com os bytes de source; com uma fonte de
+// NewSeeded, os IDs se repetem a cada execução
+func NewIDGenerator(source Source) IDGenerator {
+	return uuidGenerator{source: source}
+}
+
+type uuidGenerator struct {
+	source Source
+}
+
+func (g uuidGenerator) NewID() string {
+	return uuid.Must(uuid.NewRandomFromReader(g.source)).String()
+}
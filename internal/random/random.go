@@ -1,17 +1,61 @@
 package random
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"io"
+	"math/big"
+	mathrand "math/rand"
+	"sync"
 )
 
-var globalRand *rand.Rand
+// Source é a fonte de aleatoriedade do servidor: códigos de sessão, IDs e
+// chave dos tokens. Crypto é a de produção; NewSeeded gera sequências
+// reproduzíveis para testes e simulações.
+type Source interface {
+	io.Reader
+	// Intn retorna um número uniforme em [0,n). Entra em pânico se n <= 0 ou se
+	// a fonte falhar.
+	Intn(n int) int
+}
+
+// Crypto lê de crypto/rand
+var Crypto Source = cryptoSource{}
+
+type cryptoSource struct{}
+
+func (cryptoSource) Read(p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+func (cryptoSource) Intn(n int) int {
+	value, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(value.Int64())
+}
+
+// NewSeeded cria uma fonte determinística: a mesma semente produz sempre a
+// mesma sequência. Não é criptograficamente segura e nunca deve ser usada em
+// produção. É segura para uso concorrente, mas a ordem das chamadas entre
+// goroutines define quem recebe cada valor.
+func NewSeeded(seed int64) Source {
+	return &seededSource{rng: mathrand.New(mathrand.NewSource(seed))}
+}
 
-func init() {
-	globalRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+type seededSource struct {
+	rng   *mathrand.Rand
+	mutex sync.Mutex
 }
 
-// Intn returns a non-negative pseudo-random number in [0,n) from the global random source
-func Intn(n int) int {
-	return globalRand.Intn(n)
-} 
\ No newline at end of file
+func (s *seededSource) Read(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.rng.Read(p)
+}
+
+func (s *seededSource) Intn(n int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.rng.Intn(n)
+}
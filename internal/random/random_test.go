@@ -0,0 +1,25 @@
+package random
+
+import "testing"
+
+func TestSeededIsReproducible(t *testing.T) {
+	generate := func() []string {
+		source := NewSeeded(7)
+		codes, err := NewCodeGenerator(DefaultCodeOptions(), source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := NewIDGenerator(source)
+		return []string{codes.Generate(), ids.NewID(), codes.Generate(), ids.NewID()}
+	}
+
+	first, second := generate(), generate()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("value %d differs: %s != %s", i, first[i], second[i])
+		}
+	}
+	if first[1] == first[3] {
+		t.Fatalf("consecutive IDs should differ, got %s twice", first[1])
+	}
+}
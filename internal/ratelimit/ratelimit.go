@@ -0,0 +1,131 @@
+// Package ratelimit implementa limitadores token bucket indexados por chave (IP, usuário, sessão...).
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"flash-cards/backend/internal/clock"
+)
+
+// sweepInterval é o intervalo mínimo entre limpezas de buckets ociosos
+const sweepInterval = time.Minute
+
+// Limit define a taxa de reposição (tokens por segundo) e a capacidade do bucket
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Per cria um Limit de n eventos a cada intervalo, com a capacidade informada
+func Per(n int, interval time.Duration, burst int) Limit {
+	return Limit{Rate: float64(n) / interval.Seconds(), Burst: burst}
+}
+
+// Stats são os contadores de um limitador
+type Stats struct {
+	Allowed  uint64 `json:"allowed"`
+	Rejected uint64 `json:"rejected"`
+	Keys     int    `json:"keys"`
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter mantém um token bucket por chave
+type Limiter struct {
+	limit     Limit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	clock     clock.Clock
+	mutex     sync.Mutex
+
+	allowed  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// NewLimiter cria um limitador. Um Limit com Rate zero não limita nada.
+func NewLimiter(limit Limit, clock clock.Clock) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		buckets:   make(map[string]*bucket),
+		lastSweep: clock.Now(),
+		clock:     clock,
+	}
+}
+
+// Allow consome um token da chave. Se não houver token, retorna false e quanto
+// tempo falta até o próximo ficar disponível.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	return l.take(key, true)
+}
+
+// Check informa se a chave teria um token disponível, sem consumi-lo.
+// Uma recusa conta como rejeição nos contadores.
+func (l *Limiter) Check(key string) (bool, time.Duration) {
+	return l.take(key, false)
+}
+
+func (l *Limiter) take(key string, consume bool) (bool, time.Duration) {
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		l.rejected.Add(1)
+		wait := time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+		return false, wait
+	}
+
+	if consume {
+		b.tokens--
+		l.allowed.Add(1)
+	}
+	return true, 0
+}
+
+// sweep descarta buckets que já estariam cheios: recriá-los dá o mesmo resultado
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Stats retorna os contadores do limitador
+func (l *Limiter) Stats() Stats {
+	l.mutex.Lock()
+	keys := len(l.buckets)
+	l.mutex.Unlock()
+
+	return Stats{
+		Allowed:  l.allowed.Load(),
+		Rejected: l.rejected.Load(),
+		Keys:     keys,
+	}
+}
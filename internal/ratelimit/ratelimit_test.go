@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"flash-cards/backend/internal/clock"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		ok      bool
+		wait    time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "burst then refuse",
+			limit: Limit{Rate: 2, Burst: 3},
+			steps: []step{{0, true, 0}, {0, true, 0}, {0, true, 0}, {0, false, 500 * time.Millisecond}},
+		},
+		{
+			name:  "refill",
+			limit: Limit{Rate: 2, Burst: 1},
+			steps: []step{
+				{0, true, 0},
+				{250 * time.Millisecond, false, 250 * time.Millisecond},
+				{250 * time.Millisecond, true, 0},
+				{0, false, 500 * time.Millisecond},
+			},
+		},
+		{
+			name:  "refill stops at burst",
+			limit: Limit{Rate: 1, Burst: 2},
+			steps: []step{
+				{0, true, 0}, {0, true, 0},
+				{time.Hour, true, 0}, {0, true, 0},
+				{0, false, time.Second},
+			},
+		},
+		{
+			name:  "retry after a slow rate",
+			limit: Per(1, time.Minute, 1),
+			steps: []step{{0, true, 0}, {0, false, time.Minute}, {59 * time.Second, false, time.Second}, {time.Second, true, 0}},
+		},
+		{
+			name:  "zero rate is unlimited",
+			limit: Limit{},
+			steps: []step{{0, true, 0}, {0, true, 0}, {0, true, 0}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Unix(0, 0))
+			limiter := NewLimiter(test.limit, fake)
+			for i, s := range test.steps {
+				fake.Advance(s.advance)
+				ok, wait := limiter.Allow("key")
+				if ok != s.ok || wait.Round(time.Millisecond) != s.wait {
+					t.Fatalf("step %d: Allow = %v, %s; want %v, %s", i, ok, wait, s.ok, s.wait)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 1, Burst: 1}, clock.NewFake(time.Unix(0, 0)))
+
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Fatal("first request of a refused")
+	}
+	if ok, _ := limiter.Allow("a"); ok {
+		t.Fatal("second request of a allowed")
+	}
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Fatal("b was charged for a")
+	}
+}
+
+func TestLimiterCheckDoesNotConsume(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 1, Burst: 1}, clock.NewFake(time.Unix(0, 0)))
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Check("key"); !ok {
+			t.Fatalf("Check %d refused", i)
+		}
+	}
+	limiter.Allow("key")
+	if ok, wait := limiter.Check("key"); ok || wait != time.Second {
+		t.Fatalf("Check after Allow = %v, %s; want false, 1s", ok, wait)
+	}
+
+	stats := limiter.Stats()
+	if stats.Allowed != 1 || stats.Rejected != 1 || stats.Keys != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
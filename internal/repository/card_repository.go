@@ -1,87 +1,163 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
 	"flash-cards/backend/internal/domain"
-
-	"github.com/google/uuid"
+	"flash-cards/backend/internal/random"
 )
 
+// ErrVotingClosed indica um voto num card cuja votação já foi encerrada
+var ErrVotingClosed = errors.New("voting closed")
+
+// CardRepository é a única fonte dos cards; cada card pertence a exatamente uma sessão
 type CardRepository struct {
-	cards []domain.Card
-	mutex sync.RWMutex
+	cards    map[string][]domain.Card // SessionID -> cards, na ordem de criação
+	sessions map[string]string        // CardID -> SessionID
+	ids      random.IDGenerator
+	mutex    sync.RWMutex
 }
 
-func NewCardRepository() *CardRepository {
+func NewCardRepository(ids random.IDGenerator) *CardRepository {
 	return &CardRepository{
-		cards: make([]domain.Card, 0),
+		cards:    make(map[string][]domain.Card),
+		sessions: make(map[string]string),
+		ids:      ids,
 	}
 }
 
-func (r *CardRepository) GetAll() []domain.Card {
+// GetBySession retorna uma cópia dos cards da sessão
+func (r *CardRepository) GetBySession(sessionID string) []domain.Card {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cards := make([]domain.Card, len(r.cards[sessionID]))
+	copy(cards, r.cards[sessionID])
+	return cards
+}
+
+func (r *CardRepository) Get(cardID string) (domain.Card, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
-	return r.cards
+
+	card := r.find(cardID)
+	if card == nil {
+		return domain.Card{}, fmt.Errorf("card not found")
+	}
+	return *card, nil
 }
 
-func (r *CardRepository) Create(card domain.Card) domain.Card {
+func (r *CardRepository) Create(sessionID string, card domain.Card) domain.Card {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	card.ID = uuid.New().String()
-	r.cards = append(r.cards, card)
+	card.ID = r.ids.NewID()
+	card.SessionID = sessionID
+	r.cards[sessionID] = append(r.cards[sessionID], card)
+	r.sessions[card.ID] = sessionID
 	return card
 }
 
-func (r *CardRepository) AddVote(cardID string, vote domain.Vote) (domain.Card, error) {
+// AddVote registra o voto do participante; um novo voto dele substitui o
+// anterior. Retorna ErrVotingClosed se o card já foi revelado.
+func (r *CardRepository) AddVote(cardID string, userID string, vote domain.Vote) (domain.Card, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	for i := range r.cards {
-		if r.cards[i].ID == cardID {
-			r.cards[i].Votes = append(r.cards[i].Votes, vote.Score)
-			r.updateCardResults(&r.cards[i])
-			return r.cards[i], nil
+	card := r.find(cardID)
+	if card == nil {
+		return domain.Card{}, fmt.Errorf("card not found")
+	}
+	if card.Closed {
+		return domain.Card{}, ErrVotingClosed
+	}
+
+	// Cópias já entregues compartilham o slice, então ele nunca é alterado no lugar
+	ballots := make([]domain.Ballot, 0, len(card.Ballots)+1)
+	replaced := false
+	for _, ballot := range card.Ballots {
+		if ballot.UserID == userID {
+			ballot.Score = vote.Score
+			replaced = true
 		}
+		ballots = append(ballots, ballot)
+	}
+	if !replaced {
+		ballots = append(ballots, domain.Ballot{UserID: userID, Score: vote.Score})
 	}
-	return domain.Card{}, fmt.Errorf("card not found")
+	card.Ballots = ballots
+	r.updateCardResults(card)
+	return *card, nil
 }
 
 func (r *CardRepository) CloseVoting(cardID string) (domain.Card, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	for i := range r.cards {
-		if r.cards[i].ID == cardID {
-			r.cards[i].Closed = true
-			return r.cards[i], nil
-		}
+	card := r.find(cardID)
+	if card == nil {
+		return domain.Card{}, fmt.Errorf("card not found")
 	}
-	return domain.Card{}, fmt.Errorf("card not found")
+	card.Closed = true
+	return *card, nil
 }
 
-func (r *CardRepository) ResetAllVotes() {
+// ResetVotes zera os votos de todos os cards da sessão e retorna os cards atualizados
+func (r *CardRepository) ResetVotes(sessionID string) []domain.Card {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	for i := range r.cards {
-		r.cards[i].Votes = []int{}
-		r.cards[i].Result.Average = 0
-		r.cards[i].Result.Distribution = make(map[int]int)
-		r.cards[i].Closed = false
+	cards := r.cards[sessionID]
+	for i := range cards {
+		cards[i].Votes = []int{}
+		cards[i].Ballots = nil
+		cards[i].Result.Average = 0
+		cards[i].Result.Distribution = make(map[int]int)
+		cards[i].Closed = false
 	}
+
+	result := make([]domain.Card, len(cards))
+	copy(result, cards)
+	return result
+}
+
+// find retorna o card dentro do slice da sessão; deve ser chamado com o mutex adquirido
+func (r *CardRepository) find(cardID string) *domain.Card {
+	sessionID, exists := r.sessions[cardID]
+	if !exists {
+		return nil
+	}
+
+	cards := r.cards[sessionID]
+	for i := range cards {
+		if cards[i].ID == cardID {
+			return &cards[i]
+		}
+	}
+	return nil
 }
 
+// updateCardResults recalcula Votes e o resultado a partir de Ballots
 func (r *CardRepository) updateCardResults(card *domain.Card) {
+	card.Votes = make([]int, len(card.Ballots))
+	card.Result.Distribution = make(map[int]int)
 	sum := 0
-	for _, v := range card.Votes {
-		sum += v
+	for i, ballot := range card.Ballots {
+		card.Votes[i] = ballot.Score
+		card.Result.Distribution[ballot.Score]++
+		sum += ballot.Score
 	}
-	card.Result.Average = float64(sum) / float64(len(card.Votes))
-	card.Result.Distribution = make(map[int]int)
-	for _, v := range card.Votes {
-		card.Result.Distribution[v]++
+	card.Result.Average = 0
+	if len(card.Ballots) > 0 {
+		card.Result.Average = float64(sum) / float64(len(card.Ballots))
 	}
 }
+
+// Ping confirma que o repositório responde; veja SessionRepository.Ping
+func (r *CardRepository) Ping() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return nil
+}
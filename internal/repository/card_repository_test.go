@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/random"
+)
+
+func TestAddVote(t *testing.T) {
+	type vote struct {
+		user  string
+		score int
+	}
+
+	tests := []struct {
+		name    string
+		votes   []vote
+		closed  bool
+		want    []int
+		average float64
+		err     error
+	}{
+		{"one vote per user", []vote{{"ana", 3}, {"bia", 8}}, false, []int{3, 8}, 5.5, nil},
+		{"revote replaces", []vote{{"ana", 3}, {"bia", 8}, {"ana", 13}}, false, []int{13, 8}, 10.5, nil},
+		{"same user many times", []vote{{"ana", 1}, {"ana", 2}, {"ana", 3}}, false, []int{3}, 3, nil},
+		{"closed card", []vote{{"ana", 3}}, true, []int{}, 0, ErrVotingClosed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := NewCardRepository(random.NewIDGenerator(random.NewSeeded(1)))
+			card := repo.Create("session", domain.Card{Title: "Login", Votes: []int{}})
+			if test.closed {
+				if _, err := repo.CloseVoting(card.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var err error
+			for _, v := range test.votes {
+				if _, err = repo.AddVote(card.ID, v.user, domain.Vote{Score: v.score}); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+
+			got, err := repo.Get(card.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Votes) != len(test.want) {
+				t.Fatalf("expected votes %v, got %v", test.want, got.Votes)
+			}
+			for i := range got.Votes {
+				if got.Votes[i] != test.want[i] {
+					t.Fatalf("expected votes %v, got %v", test.want, got.Votes)
+				}
+			}
+			if got.Result.Average != test.average {
+				t.Fatalf("expected average %v, got %v", test.average, got.Result.Average)
+			}
+		})
+	}
+}
+
+func TestAddVoteDoesNotChangeCopies(t *testing.T) {
+	repo := NewCardRepository(random.NewIDGenerator(random.NewSeeded(1)))
+	card := repo.Create("session", domain.Card{Title: "Login"})
+
+	before, _ := repo.AddVote(card.ID, "ana", domain.Vote{Score: 3})
+	repo.AddVote(card.ID, "ana", domain.Vote{Score: 8})
+	if before.Votes[0] != 3 || before.Ballots[0].Score != 3 {
+		t.Fatalf("a copy returned earlier changed: %+v", before)
+	}
+}
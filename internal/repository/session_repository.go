@@ -1,54 +1,106 @@
 package repository
 
 import (
-	"fmt"
+	"errors"
+	"regexp"
+	"strings"
 	"sync"
-	"time"
 
+	"flash-cards/backend/internal/clock"
 	"flash-cards/backend/internal/domain"
 	"flash-cards/backend/internal/random"
-
-	"github.com/google/uuid"
 )
 
-const (
-	codeLength = 6
-	codeChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// maxCodeAttempts limita as tentativas de gerar um código livre antes de desistir
+const maxCodeAttempts = 32
+
+// vanityCodePattern define os códigos que podem ser escolhidos pelo owner
+var vanityCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{2,30}[A-Z0-9]$`)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrVersionMismatch indica que a sessão mudou desde a versão esperada pelo cliente
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrCodeSpaceExhausted indica que nenhum código livre foi encontrado em maxCodeAttempts tentativas
+	ErrCodeSpaceExhausted = errors.New("no free session code available")
+	// ErrInvalidCode indica um código personalizado fora de vanityCodePattern
+	ErrInvalidCode = errors.New("invalid session code")
+	// ErrCodeTaken indica que o código personalizado já está em uso
+	ErrCodeTaken = errors.New("session code already in use")
 )
 
+// CodeStats são os contadores da geração de códigos
+type CodeStats struct {
+	Generated  uint64 `json:"generated"`
+	Collisions uint64 `json:"collisions"`
+	Exhausted  uint64 `json:"exhausted"`
+	Vanity     uint64 `json:"vanity"`
+	// Used e Space dão a ocupação do espaço de códigos; colisões crescem com Used/Space
+	Used  int     `json:"used"`
+	Space float64 `json:"space"`
+}
+
+// SessionStats conta as sessões guardadas por estado e os participantes delas
+type SessionStats struct {
+	ByState map[domain.SessionState]int `json:"byState"`
+	Users   int                         `json:"users"`
+}
+
 type SessionRepository struct {
 	sessions     map[string]domain.Session // ID -> Session
 	sessionCodes map[string]string         // Code -> ID
-	users        map[string]domain.User    // UserID -> User
+	codes        random.CodeGenerator
+	ids          random.IDGenerator
+	clock        clock.Clock
+	codeStats    CodeStats
 	mutex        sync.RWMutex
 }
 
-func NewSessionRepository() *SessionRepository {
+func NewSessionRepository(codes random.CodeGenerator, ids random.IDGenerator, clock clock.Clock) *SessionRepository {
 	return &SessionRepository{
 		sessions:     make(map[string]domain.Session),
 		sessionCodes: make(map[string]string),
-		users:        make(map[string]domain.User),
+		codes:        codes,
+		ids:          ids,
+		clock:        clock,
 	}
 }
 
-func (r *SessionRepository) CreateSession() (domain.Session, string) {
+// CreateSession cria a sessão já com o owner, numa única operação. Se code
+// não for vazio, é usado como código personalizado em vez de um gerado.
+func (r *SessionRepository) CreateSession(owner domain.User, code string) (domain.Session, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	code := r.generateUniqueCode()
+	var err error
+	if code == "" {
+		code, err = r.generateUniqueCode()
+	} else {
+		code, err = r.reserveVanityCode(code)
+	}
+	if err != nil {
+		return domain.Session{}, err
+	}
+
 	session := domain.Session{
-		ID:        uuid.New().String(),
+		ID:        r.ids.NewID(),
 		Code:      code,
-		CreatedAt: time.Now(),
+		CreatedAt: r.clock.Now(),
 		State:     domain.SessionStateOpen,
-		Cards:     make([]domain.Card, 0),
-		Users:     make([]domain.User, 0),
+		Version:   1,
 	}
 
+	owner.ID = r.ids.NewID()
+	owner.Role = domain.UserRoleOwner
+	owner.SessionID = session.ID
+	owner.JoinedAt = session.CreatedAt
+	session.OwnerID = owner.ID
+	session.Users = []domain.User{owner}
+
 	r.sessions[session.ID] = session
 	r.sessionCodes[code] = session.ID
 
-	return session, code
+	return session, nil
 }
 
 func (r *SessionRepository) GetSessionByCode(code string) (domain.Session, error) {
@@ -57,114 +109,147 @@ func (r *SessionRepository) GetSessionByCode(code string) (domain.Session, error
 
 	sessionID, exists := r.sessionCodes[code]
 	if !exists {
-		return domain.Session{}, fmt.Errorf("session not found")
+		return domain.Session{}, ErrSessionNotFound
 	}
 
 	session, exists := r.sessions[sessionID]
 	if !exists {
-		return domain.Session{}, fmt.Errorf("session not found")
+		return domain.Session{}, ErrSessionNotFound
 	}
 
 	return session, nil
 }
 
-func (r *SessionRepository) AddUserToSession(code string, user domain.User) (domain.User, error) {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	sessionID, exists := r.sessionCodes[code]
-	if !exists {
-		return domain.User{}, fmt.Errorf("session not found")
-	}
+func (r *SessionRepository) GetSession(sessionID string) (domain.Session, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
 	session, exists := r.sessions[sessionID]
 	if !exists {
-		return domain.User{}, fmt.Errorf("session not found")
+		return domain.Session{}, ErrSessionNotFound
 	}
 
-	user.ID = uuid.New().String()
-	user.JoinedAt = time.Now()
-
-	session.Users = append(session.Users, user)
-	r.sessions[sessionID] = session
-	r.users[user.ID] = user
-
-	return user, nil
+	return session, nil
 }
 
-func (r *SessionRepository) UpdateSession(session domain.Session) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
+// Inspect executa check com a sessão atual sem deixar que uma alteração seja
+// intercalada, mas não altera a sessão nem a versão. Serve a operações que
+// dependem do estado da sessão e gravam em outro lugar, como os votos, que
+// não devem mudar o ETag. expectedVersion segue as regras de Mutate.
+func (r *SessionRepository) Inspect(code string, expectedVersion uint64, check func(session domain.Session) error) (domain.Session, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
-	if _, exists := r.sessions[session.ID]; !exists {
-		return fmt.Errorf("session not found")
+	sessionID, exists := r.sessionCodes[code]
+	if !exists {
+		return domain.Session{}, ErrSessionNotFound
 	}
 
-	r.sessions[session.ID] = session
-	return nil
-}
-
-func (r *SessionRepository) RemoveUserFromSession(sessionID string, userID string) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
 	session, exists := r.sessions[sessionID]
 	if !exists {
-		return fmt.Errorf("session not found")
+		return domain.Session{}, ErrSessionNotFound
 	}
 
-	for i, user := range session.Users {
-		if user.ID == userID {
-			session.Users = append(session.Users[:i], session.Users[i+1:]...)
-			r.sessions[sessionID] = session
-			delete(r.users, userID)
-			return nil
-		}
+	if expectedVersion != 0 && session.Version != expectedVersion {
+		return session, ErrVersionMismatch
 	}
-
-	return fmt.Errorf("user not found in session")
-}
-
-func (r *SessionRepository) GetSession(sessionID string) (domain.Session, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	session, exists := r.sessions[sessionID]
-	if !exists {
-		return domain.Session{}, fmt.Errorf("session not found")
+	if err := check(session); err != nil {
+		return domain.Session{}, err
 	}
-
 	return session, nil
 }
 
-func (r *SessionRepository) AddCardToSession(sessionID string, card domain.Card) error {
+// Mutate aplica mutation à sessão sob o lock de escrita, de forma que nenhuma
+// outra alteração seja intercalada. Se expectedVersion for diferente de zero e
+// não corresponder à versão atual, retorna ErrVersionMismatch sem alterar nada.
+// Se mutation retornar erro, a sessão também não é alterada. Em caso de sucesso
+// a versão é incrementada e a sessão resultante é retornada.
+func (r *SessionRepository) Mutate(code string, expectedVersion uint64, mutation func(session *domain.Session) error) (domain.Session, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	sessionID, exists := r.sessionCodes[code]
+	if !exists {
+		return domain.Session{}, ErrSessionNotFound
+	}
+
 	session, exists := r.sessions[sessionID]
 	if !exists {
-		return fmt.Errorf("session not found")
+		return domain.Session{}, ErrSessionNotFound
 	}
 
-	session.Cards = append(session.Cards, card)
+	if expectedVersion != 0 && session.Version != expectedVersion {
+		return session, ErrVersionMismatch
+	}
+
+	// Leitores podem ter cópias da sessão compartilhando o slice de usuários
+	session.Users = append([]domain.User(nil), session.Users...)
+	if err := mutation(&session); err != nil {
+		return domain.Session{}, err
+	}
+
+	// Os cards vivem no CardRepository; a cópia eventualmente preenchida não é guardada
+	session.Cards = nil
+	session.Version++
 	r.sessions[sessionID] = session
 
-	return nil
+	return session, nil
 }
 
-func (r *SessionRepository) generateUniqueCode() string {
-	for {
-		code := r.generateCode()
+func (r *SessionRepository) generateUniqueCode() (string, error) {
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		code := r.codes.Generate()
+		r.codeStats.Generated++
 		if _, exists := r.sessionCodes[code]; !exists {
-			return code
+			return code, nil
 		}
+		r.codeStats.Collisions++
+	}
+	r.codeStats.Exhausted++
+	return "", ErrCodeSpaceExhausted
+}
+
+// reserveVanityCode normaliza o código pedido para maiúsculas e confere que está livre
+func (r *SessionRepository) reserveVanityCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !vanityCodePattern.MatchString(code) {
+		return "", ErrInvalidCode
+	}
+	if _, exists := r.sessionCodes[code]; exists {
+		return "", ErrCodeTaken
 	}
+	r.codeStats.Vanity++
+	return code, nil
+}
+
+// CodeStats retorna os contadores da geração de códigos
+func (r *SessionRepository) CodeStats() CodeStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stats := r.codeStats
+	stats.Used = len(r.sessionCodes)
+	stats.Space = r.codes.Space()
+	return stats
 }
 
-func (r *SessionRepository) generateCode() string {
-	code := make([]byte, codeLength)
-	for i := range code {
-		code[i] = codeChars[random.Intn(len(codeChars))]
+// Stats conta as sessões por estado e o total de participantes
+func (r *SessionRepository) Stats() SessionStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stats := SessionStats{ByState: make(map[domain.SessionState]int)}
+	for _, session := range r.sessions {
+		stats.ByState[session.State]++
+		stats.Users += len(session.Users)
 	}
-	return string(code)
+	return stats
+}
+
+// Ping confirma que o repositório responde. Em memória, basta obter o lock:
+// uma operação travada segurando o mutex faz Ping não retornar.
+func (r *SessionRepository) Ping() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return nil
 }
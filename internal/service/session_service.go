@@ -2,160 +2,415 @@ package service
 
 import (
 	"errors"
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/clock"
 	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/logging"
+	"flash-cards/backend/internal/random"
 	"flash-cards/backend/internal/repository"
-	"time"
+	"log/slog"
+	"sync/atomic"
 )
 
+// Erros do serviço; o código, o status HTTP e as mensagens ficam no catálogo
+// de apperror
 var (
-	ErrSessionNotFound = errors.New("sessão não encontrada")
-	ErrUnauthorized    = errors.New("usuário não autorizado")
-	ErrSessionClosed   = errors.New("sessão está fechada")
+	ErrSessionNotFound = apperror.New(apperror.CodeSessionNotFound)
+	ErrUnauthorized    = apperror.New(apperror.CodeNotOwner)
+	ErrNotFacilitator  = apperror.New(apperror.CodeNotFacilitator)
+	ErrSessionClosed   = apperror.New(apperror.CodeSessionClosed)
+	ErrVotingClosed    = apperror.New(apperror.CodeVotingClosed)
+	ErrUserNotFound    = apperror.New(apperror.CodeUserNotFound)
+	ErrNotMember       = apperror.New(apperror.CodeNotMember)
+	ErrCardNotFound    = apperror.New(apperror.CodeCardNotFound)
+	ErrInvalidRole     = apperror.New(apperror.CodeInvalidRole)
+	ErrInvalidVote     = apperror.New(apperror.CodeInvalidVote)
+	ErrVersionMismatch = apperror.New(apperror.CodeVersionMismatch)
+	ErrVanityDisabled  = apperror.New(apperror.CodeVanityDisabled)
+	ErrInvalidCode     = apperror.New(apperror.CodeInvalidSessionCode)
+	ErrCodeTaken       = apperror.New(apperror.CodeSessionCodeTaken)
+	ErrCodeUnavailable = apperror.New(apperror.CodeSessionCodeFull)
 )
 
 type SessionService struct {
-	sessionRepo *repository.SessionRepository
-	cardRepo    *repository.CardRepository
+	sessionRepo SessionStore
+	cardRepo    CardStore
+	// allowVanityCodes permite que o owner escolha o código da sessão
+	allowVanityCodes bool
+	// votesCast conta os votos registrados desde a inicialização
+	votesCast atomic.Uint64
+	clock     clock.Clock
+	ids       random.IDGenerator
+	logger    *slog.Logger
 }
 
-func NewSessionService(sessionRepo *repository.SessionRepository, cardRepo *repository.CardRepository) *SessionService {
+func NewSessionService(sessionRepo SessionStore, cardRepo CardStore, allowVanityCodes bool, clock clock.Clock, ids random.IDGenerator, logger *slog.Logger) *SessionService {
 	return &SessionService{
-		sessionRepo: sessionRepo,
-		cardRepo:    cardRepo,
+		sessionRepo:      sessionRepo,
+		cardRepo:         cardRepo,
+		allowVanityCodes: allowVanityCodes,
+		clock:            clock,
+		ids:              ids,
+		logger:           logger,
 	}
 }
 
-func (s *SessionService) CreateSession(req domain.CreateSessionRequest) (domain.CreateSessionResponse, error) {
-	session, code := s.sessionRepo.CreateSession()
+// mutate aplica a alteração atomicamente no repositório, traduzindo os erros
+// dele para os erros do serviço. expectedVersion zero desativa a verificação.
+func (s *SessionService) mutate(code string, expectedVersion uint64, mutation func(session *domain.Session) error) (domain.Session, error) {
+	return s.translate(s.sessionRepo.Mutate(code, expectedVersion, mutation))
+}
 
-	// Criar o usuário owner
-	owner := domain.User{
-		Name:      req.OwnerName,
-		Role:      domain.UserRoleOwner,
-		SessionID: session.ID,
-		JoinedAt:  time.Now(),
+// inspect é o equivalente de mutate para operações que não alteram a sessão
+// nem sua versão
+func (s *SessionService) inspect(code string, expectedVersion uint64, check func(session domain.Session) error) (domain.Session, error) {
+	return s.translate(s.sessionRepo.Inspect(code, expectedVersion, check))
+}
+
+// translate converte os erros do repositório de sessões nos do serviço
+func (s *SessionService) translate(session domain.Session, err error) (domain.Session, error) {
+	switch {
+	case err == nil:
+		return session, nil
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return domain.Session{}, ErrVersionMismatch
+	case errors.Is(err, repository.ErrSessionNotFound):
+		return domain.Session{}, ErrSessionNotFound
+	default:
+		return domain.Session{}, err
 	}
+}
 
-	// Adicionar o owner à sessão através do repositório para gerar o ID
-	owner, err := s.sessionRepo.AddUserToSession(code, owner)
+func (s *SessionService) CreateSession(req domain.CreateSessionRequest) (domain.CreateSessionResponse, error) {
+	if req.Code != "" && !s.allowVanityCodes {
+		return domain.CreateSessionResponse{}, ErrVanityDisabled
+	}
+
+	name, err := normalizeName("ownerName", req.OwnerName)
 	if err != nil {
 		return domain.CreateSessionResponse{}, err
 	}
+	owner := domain.User{
+		Name: name,
+	}
 
-	// Atualizar o ownerID da sessão
-	session.OwnerID = owner.ID
-	session.State = domain.SessionStateOpen
-	session.Users = append(session.Users, owner)
-
-	// Atualizar a sessão no repositório
-	err = s.sessionRepo.UpdateSession(session)
-	if err != nil {
+	session, err := s.sessionRepo.CreateSession(owner, req.Code)
+	switch {
+	case errors.Is(err, repository.ErrInvalidCode):
+		return domain.CreateSessionResponse{}, ErrInvalidCode
+	case errors.Is(err, repository.ErrCodeTaken):
+		return domain.CreateSessionResponse{}, ErrCodeTaken
+	case errors.Is(err, repository.ErrCodeSpaceExhausted):
+		return domain.CreateSessionResponse{}, ErrCodeUnavailable
+	case err != nil:
 		return domain.CreateSessionResponse{}, err
 	}
 
+	s.logger.Info("sessão criada", logging.KeySession, session.Code, logging.KeyUserID, session.OwnerID, "vanity", req.Code != "")
+	session.Cards = make([]domain.Card, 0)
 	return domain.CreateSessionResponse{
 		Session: session,
-		Code:    code,
+		Code:    session.Code,
 	}, nil
 }
 
 func (s *SessionService) JoinSession(code string, req domain.JoinSessionRequest) (domain.User, error) {
-	session, err := s.sessionRepo.GetSessionByCode(code)
+	name, err := normalizeName("userName", req.UserName)
 	if err != nil {
-		return domain.User{}, ErrSessionNotFound
+		return domain.User{}, err
 	}
 
-	if session.State == domain.SessionStateClosed {
-		return domain.User{}, ErrSessionClosed
+	var user domain.User
+	_, err = s.mutate(code, 0, func(session *domain.Session) error {
+		if session.State == domain.SessionStateClosed {
+			return ErrSessionClosed
+		}
+
+		if nameTaken(session, name) {
+			return ErrNameTaken
+		}
+
+		// Criar novo usuário como convidado
+		user = domain.User{
+			ID:        s.ids.NewID(),
+			Name:      name,
+			Role:      domain.UserRoleGuest,
+			SessionID: session.ID,
+			JoinedAt:  s.clock.Now(),
+		}
+		session.AddUser(user)
+		return nil
+	})
+	if err != nil {
+		return domain.User{}, err
 	}
+	s.logger.Info("participante entrou", logging.KeySession, code, logging.KeyUserID, user.ID)
+	return user, nil
+}
 
-	// Criar novo usuário como convidado
-	user := domain.User{
-		Name:      req.UserName,
-		Role:      domain.UserRoleGuest,
-		SessionID: session.ID,
+func (s *SessionService) UpdateSessionState(code string, userID string, req domain.UpdateSessionStateRequest, expectedVersion uint64) (domain.Session, error) {
+	if err := validateState(req.State); err != nil {
+		return domain.Session{}, err
 	}
 
-	return s.sessionRepo.AddUserToSession(code, user)
-}
+	session, err := s.mutate(code, expectedVersion, func(session *domain.Session) error {
+		if !session.IsOwner(userID) {
+			return ErrUnauthorized
+		}
 
-func (s *SessionService) UpdateSessionState(code string, userID string, req domain.UpdateSessionStateRequest) error {
-	session, err := s.sessionRepo.GetSessionByCode(code)
+		session.UpdateState(req.State)
+		return nil
+	})
 	if err != nil {
-		return ErrSessionNotFound
+		return domain.Session{}, err
 	}
+	s.logger.Info("estado da sessão alterado", logging.KeySession, code, logging.KeyUserID, userID, "state", session.State)
+	return s.withCards(session), nil
+}
 
-	if !session.IsOwner(userID) {
-		return ErrUnauthorized
+func (s *SessionService) GetSessionByCode(code string) (domain.Session, error) {
+	session, err := s.sessionRepo.GetSessionByCode(code)
+	if err != nil {
+		return domain.Session{}, err
 	}
-
-	session.UpdateState(req.State)
-	return s.sessionRepo.UpdateSession(session)
+	return s.withCards(session), nil
 }
 
-func (s *SessionService) GetSessionByCode(code string) (domain.Session, error) {
-	return s.sessionRepo.GetSessionByCode(code)
+// withCards preenche os cards da sessão a partir do repositório de cards, a única fonte deles
+func (s *SessionService) withCards(session domain.Session) domain.Session {
+	session.Cards = s.cardRepo.GetBySession(session.ID)
+	return session
 }
 
-func (s *SessionService) CreateCardInSession(code string, userID string, card domain.Card) (domain.Card, error) {
+// GetSessionCards retorna os cards da sessão
+func (s *SessionService) GetSessionCards(code string) ([]domain.Card, error) {
 	session, err := s.sessionRepo.GetSessionByCode(code)
 	if err != nil {
-		return domain.Card{}, ErrSessionNotFound
+		return nil, ErrSessionNotFound
 	}
+	return s.cardRepo.GetBySession(session.ID), nil
+}
 
-	if !session.IsOwner(userID) {
-		return domain.Card{}, ErrUnauthorized
+// As operações sobre cards rodam dentro de mutate: as verificações de papel e
+// estado e a alteração do card acontecem sob o lock da sessão, e a versão da
+// sessão avança junto, já que os cards fazem parte da sua representação.
+
+// CreateCardInSession cria um card sem votos a partir do título e da descrição
+func (s *SessionService) CreateCardInSession(code string, userID string, req domain.CreateCardRequest, expectedVersion uint64) (domain.Card, error) {
+	title, err := normalizeText("title", req.Title, true, MaxTitleLength)
+	if err != nil {
+		return domain.Card{}, err
+	}
+	description, err := normalizeText("description", req.Description, false, MaxDescriptionLength)
+	if err != nil {
+		return domain.Card{}, err
 	}
 
-	if session.State == domain.SessionStateClosed {
-		return domain.Card{}, ErrSessionClosed
+	card := domain.Card{
+		Title:       title,
+		Description: description,
+		Votes:       []int{},
+		Result:      domain.Result{Distribution: make(map[int]int)},
 	}
+	_, err = s.mutate(code, expectedVersion, func(session *domain.Session) error {
+		if !session.IsOwner(userID) {
+			return ErrUnauthorized
+		}
+
+		if session.State == domain.SessionStateClosed {
+			return ErrSessionClosed
+		}
 
-	card = s.cardRepo.Create(card)
-	err = s.sessionRepo.AddCardToSession(session.ID, card)
+		card = s.cardRepo.Create(session.ID, card)
+		return nil
+	})
 	if err != nil {
 		return domain.Card{}, err
 	}
+	s.logger.Debug("card criado", logging.KeySession, code, logging.KeyUserID, userID, "card_id", card.ID)
 	return card, nil
 }
 
 func (s *SessionService) GetSession(sessionID string) (domain.Session, error) {
-	return s.sessionRepo.GetSession(sessionID)
+	session, err := s.sessionRepo.GetSession(sessionID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return s.withCards(session), nil
 }
 
-func (s *SessionService) LeaveSession(code string, userID string) error {
-	session, err := s.sessionRepo.GetSessionByCode(code)
+func (s *SessionService) LeaveSession(code string, userID string) (domain.User, error) {
+	var user domain.User
+	_, err := s.mutate(code, 0, func(session *domain.Session) error {
+		if current := session.GetUser(userID); current != nil {
+			user = *current
+		}
+
+		if session.IsOwner(userID) {
+			session.State = domain.SessionStateClosed
+		}
+
+		session.RemoveUser(userID)
+		return nil
+	})
 	if err != nil {
-		return ErrSessionNotFound
+		return domain.User{}, err
 	}
+	s.logger.Info("participante saiu", logging.KeySession, code, logging.KeyUserID, userID, "closed_session", user.Role == domain.UserRoleOwner)
+	return user, nil
+}
+
+func (s *SessionService) ResetSessionVotes(sessionCode string, userID string, expectedVersion uint64) ([]domain.Card, error) {
+	var cards []domain.Card
+	_, err := s.mutate(sessionCode, expectedVersion, func(session *domain.Session) error {
+		if !session.CanFacilitate(userID) {
+			return ErrNotFacilitator
+		}
 
-	if session.IsOwner(userID) {
-		session.State = domain.SessionStateClosed
+		cards = s.cardRepo.ResetVotes(session.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return cards, nil
+}
+
+// KickUser remove um participante da sessão; apenas o owner pode fazê-lo
+func (s *SessionService) KickUser(code string, ownerID string, userID string, expectedVersion uint64) (domain.User, error) {
+	var user domain.User
+	_, err := s.mutate(code, expectedVersion, func(session *domain.Session) error {
+		if !session.IsOwner(ownerID) || ownerID == userID {
+			return ErrUnauthorized
+		}
+
+		current := session.GetUser(userID)
+		if current == nil {
+			return ErrUserNotFound
+		}
 
-	session.RemoveUser(userID)
-	return s.sessionRepo.UpdateSession(session)
+		user = *current
+		session.RemoveUser(userID)
+		return nil
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	s.logger.Info("participante removido", logging.KeySession, code, logging.KeyUserID, userID, "by", ownerID)
+	return user, nil
 }
 
-func (s *SessionService) ResetSessionVotes(sessionCode string) ([]domain.Card, error) {
-	session, err := s.sessionRepo.GetSessionByCode(sessionCode)
+// VoteCard registra o voto de um participante em um card da sessão. O voto
+// não muda a versão da sessão: com muitos participantes votando, um If-Match
+// em outra alteração falharia quase sempre.
+func (s *SessionService) VoteCard(code string, userID string, cardID string, vote domain.Vote, expectedVersion uint64) (domain.Card, error) {
+	if vote.Score < 0 {
+		return domain.Card{}, ErrInvalidVote
+	}
+
+	var card domain.Card
+	_, err := s.inspect(code, expectedVersion, func(session domain.Session) error {
+		if session.GetUser(userID) == nil {
+			return ErrNotMember
+		}
+
+		if session.State == domain.SessionStateClosed {
+			return ErrSessionClosed
+		}
+
+		if !s.belongsTo(cardID, &session) {
+			return ErrCardNotFound
+		}
+
+		var err error
+		card, err = s.cardRepo.AddVote(cardID, userID, vote)
+		if errors.Is(err, repository.ErrVotingClosed) {
+			return ErrVotingClosed
+		}
+		return err
+	})
 	if err != nil {
-		return nil, ErrSessionNotFound
+		return domain.Card{}, err
 	}
+	s.votesCast.Add(1)
+	s.logger.Debug("voto registrado", logging.KeySession, code, logging.KeyUserID, userID, "card_id", cardID)
+	return card, nil
+}
+
+// CloseCardVoting encerra a votação de um card; apenas owner ou facilitador podem fazê-lo
+func (s *SessionService) CloseCardVoting(code string, userID string, cardID string, expectedVersion uint64) (domain.Card, error) {
+	var card domain.Card
+	_, err := s.mutate(code, expectedVersion, func(session *domain.Session) error {
+		if !session.CanFacilitate(userID) {
+			return ErrNotFacilitator
+		}
 
-	// Resetar votos de todos os cards da sessão
-	for i := range session.Cards {
-		session.Cards[i].Votes = []int{}
-		session.Cards[i].Result.Average = 0
-		session.Cards[i].Result.Distribution = make(map[int]int)
-		session.Cards[i].Closed = false
+		if !s.belongsTo(cardID, session) {
+			return ErrCardNotFound
+		}
+
+		var err error
+		card, err = s.cardRepo.CloseVoting(cardID)
+		return err
+	})
+	if err != nil {
+		return domain.Card{}, err
+	}
+	return card, nil
+}
+
+func (s *SessionService) belongsTo(cardID string, session *domain.Session) bool {
+	card, err := s.cardRepo.Get(cardID)
+	return err == nil && card.SessionID == session.ID
+}
+
+// UpdateUserRole promove um participante a facilitador ou o devolve a convidado; apenas o owner pode fazê-lo
+func (s *SessionService) UpdateUserRole(code string, ownerID string, userID string, role domain.UserRole, expectedVersion uint64) (domain.User, error) {
+	if role != domain.UserRoleFacilitator && role != domain.UserRoleGuest {
+		return domain.User{}, ErrInvalidRole
 	}
 
-	// Atualizar a sessão no repositório
-	err = s.sessionRepo.UpdateSession(session)
+	var user domain.User
+	_, err := s.mutate(code, expectedVersion, func(session *domain.Session) error {
+		if !session.IsOwner(ownerID) || ownerID == userID {
+			return ErrUnauthorized
+		}
+
+		for i := range session.Users {
+			if session.Users[i].ID == userID {
+				session.Users[i].Role = role
+				user = session.Users[i]
+				return nil
+			}
+		}
+		return ErrUserNotFound
+	})
 	if err != nil {
-		return nil, err
+		return domain.User{}, err
 	}
+	s.logger.Info("papel alterado", logging.KeySession, code, logging.KeyUserID, userID, "role", role, "by", ownerID)
+	return user, nil
+}
 
-	return session.Cards, nil
+// Stats conta as sessões por estado e os participantes
+func (s *SessionService) Stats() repository.SessionStats {
+	return s.sessionRepo.Stats()
+}
+
+// CodeStats retorna os contadores da geração de códigos de sessão
+func (s *SessionService) CodeStats() repository.CodeStats {
+	return s.sessionRepo.CodeStats()
+}
+
+// VotesCast retorna quantos votos foram registrados desde a inicialização
+func (s *SessionService) VotesCast() uint64 {
+	return s.votesCast.Load()
+}
+
+// Ping confirma que os repositórios de sessões e cards respondem
+func (s *SessionService) Ping() error {
+	if err := s.sessionRepo.Ping(); err != nil {
+		return err
+	}
+	return s.cardRepo.Ping()
 }
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/random"
+	"flash-cards/backend/internal/repository"
+)
+
+func newSessionService(t *testing.T) *SessionService {
+	t.Helper()
+
+	source := random.NewSeeded(1)
+	ids := random.NewIDGenerator(source)
+	codes, err := random.NewCodeGenerator(random.DefaultCodeOptions(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSessionService(
+		repository.NewSessionRepository(codes, ids, clock.System),
+		repository.NewCardRepository(ids),
+		false, clock.System, ids,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func TestUpdateSessionState(t *testing.T) {
+	tests := []struct {
+		state domain.SessionState
+		err   error
+	}{
+		{domain.SessionStateOpen, nil},
+		{domain.SessionStateClosed, nil},
+		{"PAUSED", apperror.New(apperror.CodeInvalidRequest)},
+		{"open", apperror.New(apperror.CodeInvalidRequest)},
+		{"", apperror.New(apperror.CodeInvalidRequest)},
+	}
+	for _, test := range tests {
+		t.Run(string(test.state), func(t *testing.T) {
+			service := newSessionService(t)
+			created, err := service.CreateSession(domain.CreateSessionRequest{OwnerName: "Ana"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = service.UpdateSessionState(created.Code, created.Session.OwnerID, domain.UpdateSessionStateRequest{State: test.state}, 0)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("UpdateSessionState(%q) = %v, want %v", test.state, err, test.err)
+			}
+
+			session, err := service.GetSessionByCode(created.Code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := test.state
+			if test.err != nil {
+				want = domain.SessionStateOpen
+			}
+			if session.State != want {
+				t.Fatalf("stored state %q, want %q", session.State, want)
+			}
+			if stats := service.Stats(); len(stats.ByState) != 1 || stats.ByState[want] != 1 {
+				t.Fatalf("unexpected stats %+v", stats.ByState)
+			}
+		})
+	}
+}
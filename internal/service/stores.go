@@ -0,0 +1,42 @@
+package service
+
+import (
+	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/repository"
+)
+
+// SessionStore guarda as sessões. Os erros seguem os de repository
+// (ErrSessionNotFound, ErrVersionMismatch, ErrInvalidCode...), que os
+// serviços traduzem para apperror.
+type SessionStore interface {
+	// CreateSession reserva code, ou sorteia um quando vazio, e guarda a sessão
+	CreateSession(owner domain.User, code string) (domain.Session, error)
+	GetSessionByCode(code string) (domain.Session, error)
+	GetSession(sessionID string) (domain.Session, error)
+	// Mutate aplica mutation atomicamente: se ela falhar, ou se expectedVersion
+	// for diferente de zero e da versão atual, a sessão não é alterada
+	Mutate(code string, expectedVersion uint64, mutation func(session *domain.Session) error) (domain.Session, error)
+	// Inspect executa check sem que Mutate o intercale, sem alterar a sessão
+	// nem a versão
+	Inspect(code string, expectedVersion uint64, check func(session domain.Session) error) (domain.Session, error)
+	Stats() repository.SessionStats
+	CodeStats() repository.CodeStats
+	Ping() error
+}
+
+// CardStore guarda os cards e os votos. AddVote substitui o voto anterior do
+// usuário e falha com repository.ErrVotingClosed em cards revelados.
+type CardStore interface {
+	GetBySession(sessionID string) []domain.Card
+	Get(cardID string) (domain.Card, error)
+	Create(sessionID string, card domain.Card) domain.Card
+	AddVote(cardID string, userID string, vote domain.Vote) (domain.Card, error)
+	CloseVoting(cardID string) (domain.Card, error)
+	ResetVotes(sessionID string) []domain.Card
+	Ping() error
+}
+
+var (
+	_ SessionStore = (*repository.SessionRepository)(nil)
+	_ CardStore    = (*repository.CardRepository)(nil)
+)
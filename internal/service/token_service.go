@@ -0,0 +1,144 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/domain"
+)
+
+var (
+	ErrInvalidToken = apperror.New(apperror.CodeInvalidToken)
+	ErrTokenExpired = apperror.New(apperror.CodeTokenExpired)
+	ErrTokenRevoked = apperror.New(apperror.CodeTokenRevoked)
+)
+
+// tokenHeader é o cabeçalho fixo dos tokens (JWT com HS256)
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims são as informações assinadas no token de um participante
+type Claims struct {
+	ID          string          `json:"jti"`
+	UserID      string          `json:"sub"`
+	SessionID   string          `json:"sid"`
+	SessionCode string          `json:"code"`
+	Role        domain.UserRole `json:"role"`
+	IssuedAt    int64           `json:"iat"`
+	ExpiresAt   int64           `json:"exp"`
+}
+
+// TokenService emite e valida os tokens dos participantes
+type TokenService struct {
+	key     []byte
+	ttl     time.Duration
+	revoked map[string]time.Time // UserID -> quando a revogação pode ser descartada
+	clock   clock.Clock
+	entropy io.Reader
+	mutex   sync.Mutex
+}
+
+// NewTokenService cria o serviço de tokens com a chave HMAC e a validade
+// informadas. clock define a emissão e a expiração; entropy gera os IDs dos
+// tokens e deve ser crypto/rand.Reader fora de testes.
+func NewTokenService(key []byte, ttl time.Duration, clock clock.Clock, entropy io.Reader) *TokenService {
+	return &TokenService{
+		key:     key,
+		ttl:     ttl,
+		revoked: make(map[string]time.Time),
+		clock:   clock,
+		entropy: entropy,
+	}
+}
+
+// Issue emite um token para o usuário na sessão informada
+func (s *TokenService) Issue(user domain.User, sessionCode string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(s.entropy, id); err != nil {
+		return "", err
+	}
+
+	now := s.clock.Now()
+	claims := Claims{
+		ID:          hex.EncodeToString(id),
+		UserID:      user.ID,
+		SessionID:   user.SessionID,
+		SessionCode: sessionCode,
+		Role:        user.Role,
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(s.ttl).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.sign(unsigned), nil
+}
+
+// Verify confere assinatura, validade e revogação do token e retorna suas claims
+func (s *TokenService) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return Claims{}, ErrInvalidToken
+	}
+
+	unsigned := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(unsigned))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if s.clock.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrTokenExpired
+	}
+
+	s.mutex.Lock()
+	_, revoked := s.revoked[claims.UserID]
+	s.mutex.Unlock()
+	if revoked {
+		return Claims{}, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// RevokeUser invalida todos os tokens já emitidos para o usuário
+func (s *TokenService) RevokeUser(userID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	for id, until := range s.revoked {
+		if now.After(until) {
+			delete(s.revoked, id)
+		}
+	}
+	// Depois de ttl nenhum token emitido antes da revogação continua válido
+	s.revoked[userID] = now.Add(s.ttl)
+}
+
+func (s *TokenService) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
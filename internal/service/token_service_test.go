@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/random"
+)
+
+func TestTokenVerify(t *testing.T) {
+	const ttl = time.Hour
+	ana := domain.User{ID: "ana", SessionID: "s1", Role: domain.UserRoleOwner}
+
+	tests := []struct {
+		name string
+		// change acontece entre a emissão e a verificação do token de ana
+		change func(tokens *TokenService, fake *clock.Fake, token string) string
+		err    error
+	}{
+		{"fresh", func(_ *TokenService, _ *clock.Fake, token string) string { return token }, nil},
+		{"just before expiry", func(_ *TokenService, fake *clock.Fake, token string) string {
+			fake.Advance(ttl - time.Second)
+			return token
+		}, nil},
+		{"at expiry", func(_ *TokenService, fake *clock.Fake, token string) string {
+			fake.Advance(ttl)
+			return token
+		}, ErrTokenExpired},
+		{"revoked", func(tokens *TokenService, _ *clock.Fake, token string) string {
+			tokens.RevokeUser(ana.ID)
+			return token
+		}, ErrTokenRevoked},
+		{"another user revoked", func(tokens *TokenService, _ *clock.Fake, token string) string {
+			tokens.RevokeUser("bia")
+			return token
+		}, nil},
+		{"revoked and expired", func(tokens *TokenService, fake *clock.Fake, token string) string {
+			tokens.RevokeUser(ana.ID)
+			fake.Advance(ttl)
+			return token
+		}, ErrTokenExpired},
+		{"issued after the revocation", func(tokens *TokenService, _ *clock.Fake, _ string) string {
+			tokens.RevokeUser(ana.ID)
+			token, _ := tokens.Issue(ana, "ABCD12")
+			return token
+		}, ErrTokenRevoked},
+		{"tampered signature", func(_ *TokenService, _ *clock.Fake, token string) string {
+			return token[:len(token)-2] + "xx"
+		}, ErrInvalidToken},
+		{"signed with another key", func(_ *TokenService, fake *clock.Fake, _ string) string {
+			token, _ := NewTokenService([]byte("another-key"), ttl, fake, random.NewSeeded(2)).Issue(ana, "ABCD12")
+			return token
+		}, ErrInvalidToken},
+		{"malformed", func(_ *TokenService, _ *clock.Fake, _ string) string { return "not.a.token" }, ErrInvalidToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Unix(1_700_000_000, 0))
+			tokens := NewTokenService([]byte("token-test-key"), ttl, fake, random.NewSeeded(1))
+			token, err := tokens.Issue(ana, "ABCD12")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := tokens.Verify(test.change(tokens, fake, token))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Verify = %v, want %v", err, test.err)
+			}
+			if err == nil && (claims.UserID != ana.ID || claims.SessionCode != "ABCD12" || claims.Role != ana.Role) {
+				t.Fatalf("unexpected claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestRevokeUserForgetsOldRevocations(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1_700_000_000, 0))
+	tokens := NewTokenService([]byte("token-test-key"), time.Hour, fake, random.NewSeeded(1))
+
+	tokens.RevokeUser("ana")
+	fake.Advance(time.Hour + time.Second)
+	tokens.RevokeUser("bia")
+
+	if _, kept := tokens.revoked["ana"]; kept || len(tokens.revoked) != 1 {
+		t.Fatalf("expected only bia to stay revoked, got %v", tokens.revoked)
+	}
+}
+
+func TestIssueUsesEntropyForIDs(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1_700_000_000, 0))
+	ana := domain.User{ID: "ana"}
+
+	first, _ := NewTokenService([]byte("token-test-key"), time.Hour, fake, random.NewSeeded(1)).Issue(ana, "ABCD12")
+	again, _ := NewTokenService([]byte("token-test-key"), time.Hour, fake, random.NewSeeded(1)).Issue(ana, "ABCD12")
+	if first != again {
+		t.Fatal("same seed and clock issued different tokens")
+	}
+
+	if _, err := NewTokenService([]byte("token-test-key"), time.Hour, fake, strings.NewReader("short")).Issue(ana, "ABCD12"); err == nil {
+		t.Fatal("expected an error when the entropy source runs out")
+	}
+}
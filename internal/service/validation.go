@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/domain"
+)
+
+// Limites dos textos enviados pelos clientes, contados em caracteres depois de
+// remover os espaços das pontas
+const (
+	MaxNameLength        = 40
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 2000
+)
+
+// ErrNameTaken indica que outro participante da sessão já usa o nome
+var ErrNameTaken = apperror.New(apperror.CodeNameTaken)
+
+// fieldError é um INVALID_REQUEST com o detalhe de um campo
+func fieldError(field, message string) error {
+	return apperror.New(apperror.CodeInvalidRequest).WithFields(apperror.Field{Field: field, Message: message})
+}
+
+// normalizeName remove os espaços das pontas, junta espaços repetidos e exige
+// um nome não vazio, sem caracteres de controle e de até MaxNameLength caracteres
+func normalizeName(field, name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fieldError(field, "must not be empty")
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", fieldError(field, "must not contain control characters")
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", fieldError(field, fmt.Sprintf("must have at most %d characters", MaxNameLength))
+	}
+	return name, nil
+}
+
+// normalizeText remove os espaços das pontas e limita o tamanho; quebras de
+// linha e tabulações são mantidas, os demais caracteres de controle não
+func normalizeText(field, text string, required bool, maxLength int) (string, error) {
+	text = strings.TrimSpace(text)
+	if required && text == "" {
+		return "", fieldError(field, "must not be empty")
+	}
+	if strings.IndexFunc(text, func(r rune) bool { return unicode.IsControl(r) && r != '\n' && r != '\t' }) >= 0 {
+		return "", fieldError(field, "must not contain control characters")
+	}
+	if utf8.RuneCountInString(text) > maxLength {
+		return "", fieldError(field, fmt.Sprintf("must have at most %d characters", maxLength))
+	}
+	return text, nil
+}
+
+// validateState aceita apenas os estados de domain.SessionState; o documento
+// OpenAPI já os limita, mas o serviço não depende disso
+func validateState(state domain.SessionState) error {
+	switch state {
+	case domain.SessionStateOpen, domain.SessionStateClosed:
+		return nil
+	}
+	return fieldError("state", fmt.Sprintf("must be %s or %s", domain.SessionStateOpen, domain.SessionStateClosed))
+}
+
+// nameTaken compara nomes sem diferenciar maiúsculas de minúsculas
+func nameTaken(session *domain.Session, name string) bool {
+	for _, user := range session.Users {
+		if strings.EqualFold(user.Name, name) {
+			return true
+		}
+	}
+	return false
+}
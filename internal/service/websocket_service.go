@@ -1,74 +1,317 @@
 package service
 
 import (
+	"context"
+	"log/slog"
 	"sync"
+	"time"
 
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/clock"
 	"flash-cards/backend/internal/domain"
+	"flash-cards/backend/internal/logging"
+	"flash-cards/backend/internal/random"
 	"flash-cards/backend/internal/websocket"
 )
 
+// EventServerRestarting é enviado a todos os hubs quando o servidor começa a desligar
+const EventServerRestarting = "server_restarting"
+
+var ErrShuttingDown = apperror.New(apperror.CodeShuttingDown)
+
+// ServerRestarting é o conteúdo do evento "server_restarting": o cliente deve
+// reconectar, de preferência com ?lastSeq, depois de ReconnectAfterMs
+type ServerRestarting struct {
+	ReconnectAfterMs int64 `json:"reconnectAfterMs"`
+}
+
 // WebsocketService gerencia as conexões WebSocket e broadcasts
 type WebsocketService struct {
-	hubs map[string]*websocket.Hub // Mapeia códigos de sessão para hubs
-	mutex sync.Mutex
+	hubs           map[string]*websocket.Hub // Mapeia códigos de sessão para hubs
+	sessionService *SessionService
+	hubOptions     websocket.HubOptions
+	shuttingDown   bool
+	quit           chan struct{}
+	// stopping são os hubs retirados do mapa que ainda não terminaram; retired
+	// acumula os contadores dos já encerrados. Ambos entram em Totals, para que
+	// os totais nunca diminuam.
+	stopping map[*websocket.Hub]bool
+	retired  websocket.HubStats
+	clock    clock.Clock
+	ids      random.IDGenerator
+	logger   *slog.Logger
+	mutex    sync.Mutex
 }
 
-// NewWebsocketService cria uma nova instância do serviço de WebSocket
-func NewWebsocketService() *WebsocketService {
-	return &WebsocketService{
-		hubs: make(map[string]*websocket.Hub),
+// NewWebsocketService cria uma nova instância do serviço de WebSocket e inicia
+// a rotina que encerra hubs de sessões fechadas ou ociosos
+func NewWebsocketService(sessionService *SessionService, hubOptions websocket.HubOptions, clock clock.Clock, ids random.IDGenerator, logger *slog.Logger) *WebsocketService {
+	s := &WebsocketService{
+		hubs:           make(map[string]*websocket.Hub),
+		sessionService: sessionService,
+		hubOptions:     hubOptions,
+		quit:           make(chan struct{}),
+		stopping:       make(map[*websocket.Hub]bool),
+		clock:          clock,
+		ids:            ids,
+		logger:         logger,
 	}
+	go s.reapLoop()
+	return s
 }
 
-// GetHub retorna o hub para uma sessão específica, criando um novo se não existir
-func (s *WebsocketService) GetHub(sessionCode string) *websocket.Hub {
+// GetHub retorna o hub para uma sessão específica, criando um novo se não existir.
+// Retorna ErrSessionNotFound ou ErrSessionClosed se a sessão não aceita conexões,
+// e ErrShuttingDown durante o desligamento.
+func (s *WebsocketService) GetHub(sessionCode string) (*websocket.Hub, error) {
+	session, err := s.sessionService.GetSessionByCode(sessionCode)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	if session.State == domain.SessionStateClosed {
+		return nil, ErrSessionClosed
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if s.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+
 	if hub, exists := s.hubs[sessionCode]; exists {
-		return hub
+		return hub, nil
 	}
 
-	hub := websocket.NewHub()
+	hub := websocket.NewHub(func() (interface{}, error) {
+		return s.sessionService.GetSessionByCode(sessionCode)
+	}, s.hubOptions, s.clock, s.ids, s.logger.With(logging.KeySession, sessionCode))
 	s.hubs[sessionCode] = hub
+	s.logger.Debug("hub iniciado", logging.KeySession, sessionCode, "hubs", len(s.hubs))
 	go hub.Run()
-	return hub
+	return hub, nil
+}
+
+// Shutdown avisa todos os hubs com "server_restarting", entrega o que estiver
+// pendente, fecha as conexões com CloseServiceRestart e aguarda os hubs
+// terminarem, inclusive os que já estavam sendo encerrados, ou o fim de ctx.
+// Depois dele, GetHub retorna ErrShuttingDown.
+func (s *WebsocketService) Shutdown(ctx context.Context, reconnectAfter time.Duration) error {
+	s.mutex.Lock()
+	if !s.shuttingDown {
+		close(s.quit)
+	}
+	s.shuttingDown = true
+	hubs := make([]*websocket.Hub, 0, len(s.hubs))
+	for _, hub := range s.hubs {
+		hubs = append(hubs, hub)
+	}
+	stopping := make([]*websocket.Hub, 0, len(s.stopping))
+	for hub := range s.stopping {
+		stopping = append(stopping, hub)
+	}
+	s.mutex.Unlock()
+
+	notice := ServerRestarting{ReconnectAfterMs: reconnectAfter.Milliseconds()}
+	for _, hub := range hubs {
+		hub.Broadcast(EventServerRestarting, notice)
+		hub.Stop(websocket.CloseServiceRestart, "server restarting")
+	}
+
+	var err error
+	for _, hub := range append(hubs, stopping...) {
+		if waitErr := hub.Wait(ctx); waitErr != nil {
+			err = waitErr
+		}
+	}
+	return err
 }
 
-// RemoveHub remove um hub quando a sessão é fechada
+// Ping confirma que o serviço aceita novas conexões: retorna ErrShuttingDown
+// depois que o desligamento começou
+func (s *WebsocketService) Ping() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.shuttingDown {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// RemoveHub encerra o hub de uma sessão fechada. Os broadcasts pendentes, como o
+// estado final da sessão, são entregues antes das conexões serem fechadas com
+// CloseSessionClosed.
 func (s *WebsocketService) RemoveHub(sessionCode string) {
+	if hub := s.hub(sessionCode); hub != nil {
+		s.stopHub(sessionCode, hub, websocket.CloseSessionClosed, "session closed")
+	}
+}
+
+// hub retorna o hub existente da sessão, sem criar um. Broadcasts usam apenas
+// hubs existentes: sem clientes não há a quem avisar, e quem conectar depois
+// recebe um snapshot.
+func (s *WebsocketService) hub(sessionCode string) *websocket.Hub {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if hub, exists := s.hubs[sessionCode]; exists {
-		// Fechar todos os clientes
-		for client := range hub.Clients() {
-			hub.Unregister(client)
-		}
+	return s.hubs[sessionCode]
+}
+
+// stopHub retira o hub do mapa, se ainda for o registrado para a sessão, e o
+// encerra sem esperar: quem chama pode ser um handler HTTP ou o reaper
+func (s *WebsocketService) stopHub(sessionCode string, hub *websocket.Hub, code int, reason string) {
+	s.mutex.Lock()
+	removed := s.hubs[sessionCode] == hub
+	if removed {
 		delete(s.hubs, sessionCode)
+		s.stopping[hub] = true
+	}
+	s.mutex.Unlock()
+
+	hub.Stop(code, reason)
+	if removed {
+		go s.retire(hub)
 	}
 }
 
-// BroadcastSession envia uma atualização da sessão para todos os clientes conectados
+// retire aguarda o hub encerrado e passa seus contadores para retired. Os
+// totais finais só são conhecidos depois que Run entrega o que estava pendente.
+func (s *WebsocketService) retire(hub *websocket.Hub) {
+	<-hub.Done()
+	stats := hub.Stats()
+	s.mutex.Lock()
+	delete(s.stopping, hub)
+	addStats(&s.retired, stats)
+	s.mutex.Unlock()
+}
+
+// addStats soma os contadores de stats em totals, exceto Clients
+func addStats(totals *websocket.HubStats, stats websocket.HubStats) {
+	totals.Published += stats.Published
+	totals.Dropped += stats.Dropped
+	totals.Coalesced += stats.Coalesced
+	totals.Disconnected += stats.Disconnected
+	totals.Batched += stats.Batched
+}
+
+// reapLoop verifica periodicamente os hubs até o desligamento
+func (s *WebsocketService) reapLoop() {
+	interval := time.Minute
+	if idle := s.hubOptions.IdleTimeout / 4; idle > 0 && idle < interval {
+		interval = idle
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C():
+			s.reap()
+		}
+	}
+}
+
+// reap encerra os hubs cuja sessão não existe mais ou foi fechada, e os que
+// estão sem clientes há mais de IdleTimeout
+func (s *WebsocketService) reap() {
+	s.mutex.Lock()
+	hubs := make(map[string]*websocket.Hub, len(s.hubs))
+	for code, hub := range s.hubs {
+		hubs[code] = hub
+	}
+	s.mutex.Unlock()
+
+	for code, hub := range hubs {
+		session, err := s.sessionService.GetSessionByCode(code)
+		switch {
+		case err != nil || session.State == domain.SessionStateClosed:
+			s.stopHub(code, hub, websocket.CloseSessionClosed, "session closed")
+		case s.hubOptions.IdleTimeout > 0 && hub.IdleFor() >= s.hubOptions.IdleTimeout:
+			s.stopHub(code, hub, websocket.CloseGoingAway, "idle")
+		}
+	}
+}
+
+// DisconnectUser encerra as conexões de um participante que saiu ou foi removido da sessão
+func (s *WebsocketService) DisconnectUser(sessionCode string, userID string) {
+	if hub := s.hub(sessionCode); hub != nil {
+		hub.DisconnectUser(userID, websocket.CloseRevoked, "token revoked")
+	}
+}
+
+// Stats retorna os contadores de cada hub, indexados pelo código da sessão
+func (s *WebsocketService) Stats() map[string]websocket.HubStats {
+	s.mutex.Lock()
+	hubs := make(map[string]*websocket.Hub, len(s.hubs))
+	for code, hub := range s.hubs {
+		hubs[code] = hub
+	}
+	s.mutex.Unlock()
+
+	stats := make(map[string]websocket.HubStats, len(hubs))
+	for code, hub := range hubs {
+		stats[code] = hub.Stats()
+	}
+	return stats
+}
+
+// Totals soma os contadores de todos os hubs, ativos e encerrados. Clients conta
+// apenas as conexões ativas.
+func (s *WebsocketService) Totals() websocket.HubStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	totals := s.retired
+	for _, hub := range s.hubs {
+		stats := hub.Stats()
+		totals.Clients += stats.Clients
+		addStats(&totals, stats)
+	}
+	for hub := range s.stopping {
+		addStats(&totals, hub.Stats())
+	}
+	return totals
+}
+
+// BroadcastSession envia uma atualização da sessão para todos os clientes
+// conectados, como patch contra a versão anterior ou como sessão completa.
+// Se a sessão foi fechada, o hub é encerrado depois de entregar a atualização.
 func (s *WebsocketService) BroadcastSession(session domain.Session) {
-	hub := s.GetHub(session.Code)
-	hub.Broadcast(session)
+	hub := s.hub(session.Code)
+	if hub == nil {
+		return
+	}
+
+	hub.BroadcastDocument(session.Version, session)
+	if session.State == domain.SessionStateClosed {
+		s.RemoveHub(session.Code)
+	}
 }
 
-// BroadcastCard envia uma atualização de card para todos os clientes conectados à sessão
+// BroadcastCard envia uma atualização de card para todos os clientes conectados à sessão.
+// Atualizações próximas são agrupadas numa única mensagem "cards_updated". É o
+// único caminho das mudanças em cards: os patches da sessão não as repetem.
 func (s *WebsocketService) BroadcastCard(sessionCode string, card domain.Card) {
-	hub := s.GetHub(sessionCode)
-	hub.Broadcast(card)
+	if hub := s.hub(sessionCode); hub != nil {
+		hub.BroadcastBatched("card_update", "cards_updated", "cards", card.ID, card)
+	}
 }
 
 // BroadcastUserUpdate envia uma atualização de usuário para todos os clientes conectados à sessão
 func (s *WebsocketService) BroadcastUserUpdate(sessionCode string, user domain.User, action string) {
 	message := map[string]interface{}{
-		"type": "user_update",
 		"action": action,
-		"user": user,
+		"user":   user,
+	}
+
+	if hub := s.hub(sessionCode); hub != nil {
+		hub.Broadcast("user_update", message)
 	}
-	
-	hub := s.GetHub(sessionCode)
-	hub.Broadcast(message)
-} 
\ No newline at end of file
+}
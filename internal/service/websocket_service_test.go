@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/random"
+	"flash-cards/backend/internal/websocket"
+)
+
+func TestRemoveHubDoesNotWaitForTheHub(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ids := random.NewIDGenerator(random.NewSeeded(1))
+	s := NewWebsocketService(newSessionService(t), websocket.DefaultHubOptions(), clock.System, ids, logger)
+
+	// Sem Run, o hub só termina quando o teste deixar: RemoveHub não pode
+	// depender disso para retornar
+	hub := websocket.NewHub(nil, websocket.DefaultHubOptions(), clock.System, ids, logger)
+	hub.Broadcast("card_update", map[string]string{"id": "c1"})
+	s.mutex.Lock()
+	s.hubs["ABCD12"] = hub
+	s.mutex.Unlock()
+
+	removed := make(chan struct{})
+	go func() {
+		s.RemoveHub("ABCD12")
+		close(removed)
+	}()
+	select {
+	case <-removed:
+	case <-time.After(time.Second):
+		t.Fatal("RemoveHub waited for the hub to finish")
+	}
+	if got := s.hub("ABCD12"); got != nil {
+		t.Fatal("the hub is still registered")
+	}
+
+	// Enquanto o hub encerra, o desligamento espera por ele
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx, time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v before the stopping hub finished", err)
+	}
+
+	go hub.Run()
+	if err := s.Shutdown(context.Background(), time.Second); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	// Os contadores do hub passam para os totais assim que ele termina
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mutex.Lock()
+		retired := len(s.stopping) == 0
+		s.mutex.Unlock()
+		if retired {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the hub was not retired")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if totals := s.Totals(); totals.Published != hub.Stats().Published || totals.Published == 0 {
+		t.Fatalf("totals %+v, hub %+v", totals, hub.Stats())
+	}
+}
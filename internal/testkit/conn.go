@@ -0,0 +1,163 @@
+package testkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flash-cards/backend/pkg/client"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conn é uma conexão WebSocket de um participante. As asserções consomem os
+// eventos em ordem: os que não correspondem ao esperado são descartados.
+type Conn struct {
+	sub *client.Subscription
+	t   testing.TB
+}
+
+// Connect abre o WebSocket do participante e aguarda a conexão; ela é fechada
+// ao final do teste
+func (p *Participant) Connect(opts ...client.SubscribeOptions) *Conn {
+	p.t.Helper()
+
+	var options client.SubscribeOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	sub, err := p.Subscribe(context.Background(), p.Code, options)
+	if err != nil {
+		p.t.Fatalf("testkit: %s connecting: %v", p.User.Name, err)
+	}
+	p.t.Cleanup(sub.Close)
+	return &Conn{sub: sub, t: p.t}
+}
+
+// Close fecha a conexão
+func (c *Conn) Close() {
+	c.sub.Close()
+}
+
+// LastSeq retorna o seq do último evento recebido, para retomar com outra conexão
+func (c *Conn) LastSeq() uint64 {
+	return c.sub.LastSeq()
+}
+
+// Stream retorna o Snapshot.Stream do último snapshot recebido, para retomar
+// com outra conexão junto com LastSeq
+func (c *Conn) Stream() string {
+	return c.sub.Stream()
+}
+
+// Next retorna o próximo evento
+func (c *Conn) Next() client.Event {
+	c.t.Helper()
+
+	select {
+	case event, ok := <-c.sub.Events:
+		if !ok {
+			c.t.Fatalf("connection closed while waiting for an event: %v", c.sub.Err())
+		}
+		return event
+	case <-time.After(Timeout):
+		c.t.Fatalf("no event within %s", Timeout)
+	}
+	return client.Event{}
+}
+
+// Expect descarta eventos até receber um do tipo informado
+func (c *Conn) Expect(eventType string) client.Event {
+	c.t.Helper()
+	return c.ExpectMatch(eventType, func(client.Event) bool { return true })
+}
+
+// ExpectMatch descarta eventos até receber um do tipo informado para o qual
+// match retorne true
+func (c *Conn) ExpectMatch(eventType string, match func(client.Event) bool) client.Event {
+	c.t.Helper()
+
+	deadline := time.After(Timeout)
+	for {
+		select {
+		case event, ok := <-c.sub.Events:
+			if !ok {
+				c.t.Fatalf("connection closed while waiting for %s: %v", eventType, c.sub.Err())
+			}
+			if event.Type == eventType && match(event) {
+				return event
+			}
+		case <-deadline:
+			c.t.Fatalf("no matching %s event within %s", eventType, Timeout)
+		}
+	}
+}
+
+// ExpectCard aguarda um card que satisfaça match, venha ele num "card_update"
+// ou num "cards_updated" agrupado pelo hub
+func (c *Conn) ExpectCard(match func(client.Card) bool) client.Card {
+	c.t.Helper()
+
+	deadline := time.After(Timeout)
+	for {
+		select {
+		case event, ok := <-c.sub.Events:
+			if !ok {
+				c.t.Fatalf("connection closed while waiting for a card: %v", c.sub.Err())
+			}
+			var cards []client.Card
+			switch event.Type {
+			case client.EventCardUpdate:
+				if card, err := event.Card(); err == nil {
+					cards = []client.Card{card}
+				}
+			case client.EventCardsUpdated:
+				cards, _ = event.Cards()
+			}
+			for _, card := range cards {
+				if match(card) {
+					return card
+				}
+			}
+		case <-deadline:
+			c.t.Fatalf("no matching card within %s", Timeout)
+		}
+	}
+}
+
+// ExpectUser aguarda um "user_update" com a ação informada para o participante
+func (c *Conn) ExpectUser(action, name string) client.UserUpdate {
+	c.t.Helper()
+
+	var found client.UserUpdate
+	c.ExpectMatch(client.EventUserUpdate, func(event client.Event) bool {
+		update, err := event.UserUpdate()
+		if err == nil && update.Action == action && update.User.Name == name {
+			found = update
+			return true
+		}
+		return false
+	})
+	return found
+}
+
+// ExpectClosed descarta eventos até o servidor fechar a conexão com o código informado
+func (c *Conn) ExpectClosed(code int) {
+	c.t.Helper()
+
+	deadline := time.After(Timeout)
+	for {
+		select {
+		case _, ok := <-c.sub.Events:
+			if ok {
+				continue
+			}
+			if !websocket.IsCloseError(c.sub.Err(), code) {
+				c.t.Fatalf("expected close %d, got %v", code, c.sub.Err())
+			}
+			return
+		case <-deadline:
+			c.t.Fatalf("connection not closed within %s", Timeout)
+		}
+	}
+}
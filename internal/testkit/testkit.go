@@ -0,0 +1,211 @@
+// Package testkit sobe o servidor completo num httptest.Server e oferece
+// atalhos para os testes de ponta a ponta: criar sessões, entrar nelas, abrir
+// conexões WebSocket e conferir os eventos recebidos. As chamadas à API usam o
+// SDK de pkg/client, como um cliente real.
+//
+//	srv := testkit.NewServer(t)
+//	owner := srv.CreateSession("Ana")
+//	guest := srv.Join(owner.Code, "Bia")
+//	conn := guest.Connect()
+//	card := owner.CreateCard("Login")
+//	conn.ExpectCard(func(c client.Card) bool { return c.ID == card.ID })
+package testkit
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"flash-cards/backend/internal/app"
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/config"
+	"flash-cards/backend/internal/handler"
+	"flash-cards/backend/internal/random"
+	"flash-cards/backend/pkg/client"
+)
+
+// Timeout é quanto as asserções esperam por um evento
+const Timeout = 5 * time.Second
+
+// Server é o servidor completo rodando num httptest.Server
+type Server struct {
+	*httptest.Server
+	App    *app.App
+	Config config.Config
+	// API é um cliente sem token, apontado para o servidor
+	API *client.Client
+
+	t testing.TB
+}
+
+// Option ajusta as dependências do servidor antes da montagem
+type Option func(*app.Options)
+
+// WithConfig altera a configuração de teste
+func WithConfig(change func(cfg *config.Config)) Option {
+	return func(opts *app.Options) { change(&opts.Config) }
+}
+
+// WithClock usa o relógio informado. Com um clock.Fake, os lotes de
+// cards_updated só são entregues quando o teste avança o relógio além de
+// Websocket.BatchWindow; zere a janela para receber cada card_update na hora.
+func WithClock(c clock.Clock) Option {
+	return func(opts *app.Options) { opts.Clock = c }
+}
+
+// WithRandom usa a fonte de aleatoriedade informada nos códigos, IDs e tokens
+func WithRandom(source random.Source) Option {
+	return func(opts *app.Options) { opts.Random = source }
+}
+
+// WithSeed torna códigos de sessão, IDs e tokens reproduzíveis: dois servidores
+// com a mesma semente e a mesma sequência de requisições geram os mesmos valores
+func WithSeed(seed int64) Option {
+	return WithRandom(random.NewSeeded(seed))
+}
+
+// Config retorna a configuração padrão dos testes: a de produção sem limites
+// de requisições, que testes rápidos atingiriam, e com uma chave de tokens fixa
+func Config() config.Config {
+	cfg := config.Default()
+	cfg.Token.Secret = "testkit-token-secret"
+	cfg.RateLimits = handler.RateLimits{}
+	return cfg
+}
+
+// NewServer monta e inicia o servidor; ele é encerrado ao final do teste
+func NewServer(t testing.TB, options ...Option) *Server {
+	t.Helper()
+
+	opts := app.Options{Config: Config()}
+	for _, option := range options {
+		option(&opts)
+	}
+	if err := opts.Config.Validate(); err != nil {
+		t.Fatalf("testkit: invalid config: %v", err)
+	}
+
+	application, err := app.New(opts)
+	if err != nil {
+		t.Fatalf("testkit: building app: %v", err)
+	}
+	server := httptest.NewServer(application)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		application.Shutdown(ctx)
+		server.Close()
+	})
+
+	return &Server{
+		Server: server,
+		App:    application,
+		Config: opts.Config,
+		API:    client.New(server.URL, client.WithHTTPClient(server.Client())),
+		t:      t,
+	}
+}
+
+// Participant é um participante de uma sessão, com o cliente já autenticado
+type Participant struct {
+	*client.Client
+	User client.User
+	Code string
+
+	t testing.TB
+}
+
+// CreateSession cria uma sessão e retorna o owner
+func (s *Server) CreateSession(ownerName string) *Participant {
+	s.t.Helper()
+
+	created, err := s.API.CreateSession(context.Background(), ownerName, "")
+	if err != nil {
+		s.t.Fatalf("testkit: creating session: %v", err)
+	}
+	var owner client.User
+	for _, user := range created.Session.Users {
+		if user.ID == created.Session.OwnerID {
+			owner = user
+		}
+	}
+	return &Participant{
+		Client: s.API.WithToken(created.Token),
+		User:   owner,
+		Code:   created.Code,
+		t:      s.t,
+	}
+}
+
+// Join entra na sessão como convidado
+func (s *Server) Join(code, name string) *Participant {
+	s.t.Helper()
+
+	participant, err := s.API.JoinSession(context.Background(), code, name)
+	if err != nil {
+		s.t.Fatalf("testkit: %s joining %s: %v", name, code, err)
+	}
+	return &Participant{
+		Client: s.API.WithToken(participant.Token),
+		User:   participant.User,
+		Code:   code,
+		t:      s.t,
+	}
+}
+
+// CreateCard cria um card na sessão do participante
+func (p *Participant) CreateCard(title string) client.Card {
+	p.t.Helper()
+
+	card, err := p.Client.CreateCard(context.Background(), p.Code, title, "")
+	if err != nil {
+		p.t.Fatalf("testkit: creating card %q: %v", title, err)
+	}
+	return *card
+}
+
+// Vote vota no card
+func (p *Participant) Vote(cardID string, score int) client.Card {
+	p.t.Helper()
+
+	card, err := p.Client.Vote(context.Background(), p.Code, cardID, score)
+	if err != nil {
+		p.t.Fatalf("testkit: %s voting: %v", p.User.Name, err)
+	}
+	return *card
+}
+
+// Reveal revela o card
+func (p *Participant) Reveal(cardID string) client.Card {
+	p.t.Helper()
+
+	card, err := p.Client.Reveal(context.Background(), p.Code, cardID)
+	if err != nil {
+		p.t.Fatalf("testkit: revealing card: %v", err)
+	}
+	return *card
+}
+
+// Session retorna o estado atual da sessão
+func (p *Participant) Session() client.Session {
+	p.t.Helper()
+
+	session, err := p.GetSession(context.Background(), p.Code)
+	if err != nil {
+		p.t.Fatalf("testkit: getting session: %v", err)
+	}
+	return *session
+}
+
+// RequireCode falha o teste se err não for um erro da API com o código informado
+func RequireCode(t testing.TB, err error, code string) *client.APIError {
+	t.Helper()
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != code {
+		t.Fatalf("expected API error %s, got %v", code, err)
+	}
+	return apiErr
+}
@@ -1,54 +1,127 @@
 package websocket
 
 import (
-	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"flash-cards/backend/internal/apperror"
+	"flash-cards/backend/internal/logging"
+
 	"github.com/gorilla/websocket"
 )
 
+// Subprotocolos aceitos. Com SubprotocolPatch (ou nenhum), mudanças na sessão
+// chegam como "session_patch"; com SubprotocolFull, como "session_update" com a
+// sessão completa.
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	SubprotocolPatch = "poker.patch"
+	SubprotocolFull  = "poker.full"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Em produção, você deve implementar uma verificação adequada de origem
-	},
+// newUpgrader monta o upgrader a partir das opções do hub
+func newUpgrader(options HubOptions) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  options.ReadBufferSize,
+		WriteBufferSize: options.WriteBufferSize,
+		Subprotocols:    []string{SubprotocolPatch, SubprotocolFull},
+		CheckOrigin: func(r *http.Request) bool {
+			if len(options.AllowedOrigins) == 0 {
+				return true
+			}
+			// Clientes que não são navegadores não enviam Origin
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			for _, allowed := range options.AllowedOrigins {
+				if allowed == "*" || allowed == origin {
+					return true
+				}
+			}
+			return false
+		},
+	}
 }
 
-// ServeWs gerencia a conexão WebSocket
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+// ServeWs gerencia a conexão WebSocket. A primeira mensagem de uma nova conexão
+// é um snapshot da sessão; um cliente que reconecta pode informar o último
+// evento recebido em ?lastSeq=N, e o Snapshot.Stream em ?stream, para receber
+// apenas o que perdeu. userID identifica o participante autenticado dono da
+// conexão.
+func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, userID string) {
+	var lastSeq uint64
+	resume := false
+	if raw := r.URL.Query().Get("lastSeq"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			apperror.Write(w, r, apperror.New(apperror.CodeInvalidRequest).WithFields(apperror.Field{
+				Field:   "lastSeq",
+				Message: "must be a non-negative integer",
+			}))
+			return
+		}
+		lastSeq = parsed
+		resume = true
+	}
+
+	clientID := hub.ids.NewID()
+	logging.AddFields(r.Context(), logging.KeyClientID, clientID)
+	logger := hub.logger.With(logging.KeyClientID, clientID, logging.KeyUserID, userID)
+
+	conn, err := newUpgrader(hub.options).Upgrade(w, r, nil)
 	if err != nil {
-		log.Println(err)
+		logger.Warn("falha no upgrade da conexão", "error", err)
 		return
 	}
 
 	client := &Client{
-		hub:  hub,
-		send: make(chan []byte, 256),
+		hub:     hub,
+		send:    make(chan []byte, hub.options.SendBufferSize),
+		userID:  userID,
+		logger:  logger,
+		resume:  resume,
+		lastSeq: lastSeq,
+		stream:  r.URL.Query().Get("stream"),
+
+		fullSnapshots: conn.Subprotocol() == SubprotocolFull,
 	}
 
-	client.hub.register <- client
+	if !hub.track() {
+		rejectStopped(conn, hub)
+		return
+	}
+	select {
+	case hub.register <- client:
+	case <-hub.done:
+		hub.writers.Done()
+		rejectStopped(conn, hub)
+		return
+	}
 
 	go client.writePump(conn)
 	go client.readPump(conn)
 }
 
+// rejectStopped fecha uma conexão recém-aberta num hub que está parando
+func rejectStopped(conn *websocket.Conn, hub *Hub) {
+	hub.mutex.Lock()
+	code, reason := hub.stopCode, hub.stopReason
+	hub.mutex.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(hub.options.WriteWait))
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	conn.Close()
+}
+
 func (c *Client) readPump(conn *websocket.Conn) {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.Unregister(c)
 		conn.Close()
 	}()
 
-	conn.SetReadLimit(maxMessageSize)
+	pongWait := c.hub.options.PongWait
+	conn.SetReadLimit(c.hub.options.MaxMessageSize)
 	conn.SetReadDeadline(time.Now().Add(pongWait))
 	conn.SetPongHandler(func(string) error {
 		conn.SetReadDeadline(time.Now().Add(pongWait))
@@ -59,7 +132,7 @@ func (c *Client) readPump(conn *websocket.Conn) {
 		_, _, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("erro: %v", err)
+				c.logger.Warn("conexão encerrada inesperadamente", "error", err)
 			}
 			break
 		}
@@ -67,10 +140,12 @@ func (c *Client) readPump(conn *websocket.Conn) {
 }
 
 func (c *Client) writePump(conn *websocket.Conn) {
-	ticker := time.NewTicker(pingPeriod)
+	writeWait := c.hub.options.WriteWait
+	ticker := time.NewTicker(c.hub.options.PongWait * 9 / 10)
 	defer func() {
 		ticker.Stop()
 		conn.Close()
+		c.hub.writers.Done()
 	}()
 
 	for {
@@ -78,7 +153,11 @@ func (c *Client) writePump(conn *websocket.Conn) {
 		case message, ok := <-c.send:
 			conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMessage := []byte{}
+				if c.closeCode != 0 {
+					closeMessage = websocket.FormatCloseMessage(c.closeCode, c.closeReason)
+				}
+				conn.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
@@ -98,4 +177,4 @@ func (c *Client) writePump(conn *websocket.Conn) {
 			}
 		}
 	}
-} 
\ No newline at end of file
+}
@@ -1,83 +1,755 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/jsonpatch"
+	"flash-cards/backend/internal/random"
+)
+
+// Tipos de evento gerados pelo próprio hub
+const (
+	EventSnapshot      = "snapshot"
+	EventSessionUpdate = "session_update"
+	EventSessionPatch  = "session_patch"
 )
 
+// Event é o envelope enviado aos clientes. Seq cresce monotonicamente por sessão
+// e permite que um cliente reconectado peça apenas os eventos perdidos.
+type Event struct {
+	Seq  uint64          `json:"seq"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+
+	// batchType e key identificam eventos que podem ser agrupados num lote
+	batchType string
+	key       string
+	// collection é a lista do documento da sessão que o evento atualiza; ver
+	// BroadcastBatched
+	collection string
+
+	// document indica que Data é o novo estado completo do documento da sessão,
+	// na versão stateVersion
+	document     bool
+	stateVersion uint64
+
+	// fullType e fullData são a forma enviada aos clientes que pediram snapshots
+	// completos, quando diferente de Type e Data
+	fullType string
+	fullData json.RawMessage
+}
+
+// SnapshotFunc retorna o estado completo da sessão (sessão, cards e usuários)
+type SnapshotFunc func() (interface{}, error)
+
+// Snapshot é o conteúdo do evento "snapshot", enviado como primeira mensagem de
+// uma nova conexão ou quando o replay não é possível. Stream identifica a
+// sequência de seqs do hub: um hub recriado (depois de ocioso ou de um
+// reinício do servidor) recomeça do seq zero com outro Stream, e o cliente deve
+// informá-lo em ?stream ao retomar para que seqs de hubs diferentes não se
+// misturem.
+type Snapshot struct {
+	Session  interface{} `json:"session"`
+	Version  uint64      `json:"version"`
+	Stream   string      `json:"stream"`
+	Presence Presence    `json:"presence"`
+}
+
+// Presence descreve quem está conectado ao hub no momento do snapshot
+type Presence struct {
+	Connected int `json:"connected"`
+}
+
+// SessionPatch é o conteúdo do evento "session_patch": aplicado a um documento
+// na versão BaseVersion, produz o documento na versão Version. As listas
+// atualizadas por eventos de item (os cards) não aparecem nos patches; ver
+// BroadcastBatched. Os participantes, ao contrário, mudam apenas por patches:
+// "user_update" é só um aviso.
+type SessionPatch struct {
+	BaseVersion uint64                `json:"baseVersion"`
+	Version     uint64                `json:"version"`
+	Ops         []jsonpatch.Operation `json:"ops"`
+}
+
+// HubStats são os contadores acumulados de um hub
+type HubStats struct {
+	Clients      int    `json:"clients"`
+	Published    uint64 `json:"published"`
+	Dropped      uint64 `json:"dropped"`
+	Coalesced    uint64 `json:"coalesced"`
+	Disconnected uint64 `json:"disconnected"`
+	Batched      uint64 `json:"batched"`
+}
+
 // Hub mantém o conjunto de conexões WebSocket ativas
 type Hub struct {
 	clients    map[*Client]bool
-	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
 	mutex      sync.Mutex
+	options    HubOptions
+	logger     *slog.Logger
+	// clock mede a ociosidade e a janela de agrupamento; ids gera os IDs das conexões
+	clock clock.Clock
+	ids   random.IDGenerator
+	// stream identifica esta instância do hub; ver Snapshot.Stream
+	stream string
+
+	// Fila de entrada: Broadcast apenas acrescenta e sinaliza, nunca bloqueia
+	pending      []Event
+	pendingMutex sync.Mutex
+	wake         chan struct{}
+
+	seq      uint64
+	history  []outbound
+	snapshot SnapshotFunc
+
+	// Último documento da sessão enviado aos clientes e sua versão
+	document        interface{}
+	documentVersion uint64
+	// stateVersion é a versão de origem do último documento publicado;
+	// documentos mais antigos chegam atrasados e são descartados
+	stateVersion uint64
+	// collections são as listas do documento mantidas pelos eventos de item
+	// (BroadcastBatched); os patches da sessão nunca as alteram
+	collections map[string]bool
+
+	// hasStale indica que algum cliente aguarda um snapshot
+	hasStale bool
+
+	// Lote aberto e o prazo para enviá-lo
+	batch         *batch
+	batchDeadline <-chan time.Time
+
+	// stop é fechado por Stop; done é fechado quando Run retorna. stopping é
+	// protegido por mutex e impede que novas conexões sejam contadas em writers.
+	stop       chan struct{}
+	stopOnce   sync.Once
+	stopping   bool
+	stopCode   int
+	stopReason string
+	done       chan struct{}
+	writers    sync.WaitGroup
+
+	// emptySince é quando o último cliente saiu; vale apenas com o hub vazio
+	emptySince time.Time
+
+	published    atomic.Uint64
+	dropped      atomic.Uint64
+	coalesced    atomic.Uint64
+	disconnected atomic.Uint64
+	batched      atomic.Uint64
+}
+
+// outbound é uma mensagem já serializada. full, quando presente, é a variante
+// para clientes que pediram snapshots completos.
+type outbound struct {
+	seq     uint64
+	message []byte
+	full    []byte
+}
+
+func (o outbound) messageFor(client *Client) []byte {
+	if client.fullSnapshots && o.full != nil {
+		return o.full
+	}
+	return o.message
+}
+
+// batch acumula a versão mais recente de cada item, na ordem da primeira aparição
+type batch struct {
+	eventType  string
+	batchType  string
+	collection string
+	order      []string
+	items      map[string]json.RawMessage
 }
 
 // Client representa uma conexão WebSocket
 type Client struct {
-	hub  *Hub
-	send chan []byte
+	hub    *Hub
+	send   chan []byte
+	userID string
+	// logger carrega a sessão, o usuário e o ID da conexão
+	logger *slog.Logger
+
+	// resume indica que o cliente informou lastSeq e quer o replay do intervalo
+	// perdido; stream é o Snapshot.Stream que ele conhecia, vazio se não informado
+	resume  bool
+	lastSeq uint64
+	stream  string
+
+	// fullSnapshots indica que o cliente negociou SubprotocolFull e recebe a
+	// sessão completa em vez de patches
+	fullSnapshots bool
+
+	// stale indica que a fila do cliente foi descartada e ele aguarda um snapshot
+	stale bool
+
+	// closeCode e closeReason são definidos antes de fechar send quando o hub derruba o cliente
+	closeCode   int
+	closeReason string
 }
 
-// NewHub cria uma nova instância do Hub
-func NewHub() *Hub {
+// NewHub cria uma nova instância do Hub. logger deve carregar o código da sessão.
+func NewHub(snapshot SnapshotFunc, options HubOptions, clock clock.Clock, ids random.IDGenerator, logger *slog.Logger) *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		options:    options,
+		logger:     logger,
+		clock:      clock,
+		ids:        ids,
+		stream:     ids.NewID(),
+		wake:       make(chan struct{}, 1),
+		history:    make([]outbound, 0, options.HistorySize),
+		snapshot:   snapshot,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		emptySince: clock.Now(),
 	}
 }
 
-// Run inicia o hub e gerencia as conexões
+// Run inicia o hub e gerencia as conexões até que Stop seja chamado
 func (h *Hub) Run() {
+	defer close(h.done)
+
 	for {
 		select {
+		case <-h.stop:
+			h.mutex.Lock()
+			for _, event := range h.takePending() {
+				h.enqueue(event)
+			}
+			h.flushBatch()
+			h.logger.Info("hub encerrado", "close_code", h.stopCode, "reason", h.stopReason, "clients", len(h.clients))
+			for client := range h.clients {
+				h.disconnect(client, h.stopCode, h.stopReason)
+			}
+			h.mutex.Unlock()
+			return
+
 		case client := <-h.register:
 			h.mutex.Lock()
-			h.clients[client] = true
+			h.attach(client)
+			h.refreshStale()
 			h.mutex.Unlock()
 
 		case client := <-h.unregister:
 			h.mutex.Lock()
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
+				h.remove(client)
 				close(client.send)
+				client.logger.Debug("cliente desconectado", "clients", len(h.clients))
 			}
 			h.mutex.Unlock()
 
-		case message := <-h.broadcast:
+		case <-h.wake:
 			h.mutex.Lock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
+			for _, event := range h.takePending() {
+				h.enqueue(event)
 			}
+			h.refreshStale()
 			h.mutex.Unlock()
+
+		case <-h.batchDeadline:
+			h.mutex.Lock()
+			h.flushBatch()
+			h.refreshStale()
+			h.mutex.Unlock()
+		}
+	}
+}
+
+// attach adiciona o cliente ao hub. Se ele informou lastSeq e o intervalo ainda
+// está no histórico, recebe o replay; caso contrário fica aguardando snapshot.
+func (h *Hub) attach(client *Client) {
+	h.clients[client] = true
+	client.logger.Debug("cliente conectado", "clients", len(h.clients), "resume", client.resume, "last_seq", client.lastSeq)
+
+	if client.resume && h.canReplay(client.stream, client.lastSeq) {
+		for _, entry := range h.history {
+			if entry.seq > client.lastSeq {
+				h.deliver(client, entry)
+			}
+		}
+		return
+	}
+
+	client.stale = true
+	h.hasStale = true
+}
+
+// canReplay informa se todos os eventos posteriores a lastSeq estão no
+// histórico. Um stream diferente, ou um lastSeq à frente do hub, indica que o
+// cliente acompanhava outra instância do hub e precisa de um snapshot.
+func (h *Hub) canReplay(stream string, lastSeq uint64) bool {
+	if stream != "" && stream != h.stream {
+		return false
+	}
+	if lastSeq > h.seq {
+		return false
+	}
+	if lastSeq == h.seq {
+		return true
+	}
+	return len(h.history) > 0 && lastSeq+1 >= h.history[0].seq
+}
+
+// refreshStale envia um snapshot a todos os clientes que aguardam um. O snapshot
+// é gerado dentro de Run, então nenhum broadcast é intercalado: o cliente recebe
+// o snapshot com seq N e em seguida os eventos N+1, N+2... Um evento cuja
+// mudança já aparece no snapshot pode chegar depois dele, mas todos os eventos
+// carregam o estado completo da entidade, então reaplicá-lo não altera o resultado.
+func (h *Hub) refreshStale() {
+	for h.hasStale {
+		h.hasStale = false
+
+		stale := make([]*Client, 0)
+		for client := range h.clients {
+			if client.stale {
+				stale = append(stale, client)
+			}
+		}
+
+		message, ok := h.snapshotMessage()
+		for _, client := range stale {
+			client.stale = false
+			if _, connected := h.clients[client]; !connected || !ok {
+				continue
+			}
+			select {
+			case client.send <- message:
+			default:
+				h.disconnect(client, CloseSlowConsumer, "slow consumer")
+			}
 		}
 	}
 }
 
-// Broadcast envia uma mensagem para todos os clientes conectados
-func (h *Hub) Broadcast(message interface{}) {
-	jsonMessage, err := json.Marshal(message)
+// takePending esvazia a fila de entrada
+func (h *Hub) takePending() []Event {
+	h.pendingMutex.Lock()
+	defer h.pendingMutex.Unlock()
+
+	events := h.pending
+	h.pending = nil
+	return events
+}
+
+// enqueue publica o evento ou o acumula no lote aberto. Um evento não agrupável
+// envia o lote pendente antes, preservando a ordem das atualizações.
+func (h *Hub) enqueue(event Event) {
+	if event.document {
+		if event.stateVersion < h.stateVersion {
+			return
+		}
+		h.stateVersion = event.stateVersion
+		h.flushBatch()
+		var state interface{}
+		if err := json.Unmarshal(event.Data, &state); err != nil {
+			h.logger.Error("Erro ao decodificar documento", "error", err)
+			return
+		}
+		h.syncDocument(state)
+		return
+	}
+
+	if event.batchType == "" || h.options.BatchWindow <= 0 {
+		h.flushBatch()
+		h.mergeItems(event.collection, event.Data)
+		h.publish(event)
+		return
+	}
+
+	if h.batch != nil && (h.batch.batchType != event.batchType || h.batch.collection != event.collection) {
+		h.flushBatch()
+	}
+	if h.batch == nil {
+		h.batch = &batch{
+			eventType:  event.Type,
+			batchType:  event.batchType,
+			collection: event.collection,
+			items:      make(map[string]json.RawMessage),
+		}
+		h.batchDeadline = h.clock.After(h.options.BatchWindow)
+	}
+
+	if _, exists := h.batch.items[event.key]; exists {
+		h.batched.Add(1)
+	} else {
+		h.batch.order = append(h.batch.order, event.key)
+	}
+	h.batch.items[event.key] = event.Data
+
+	if h.options.MaxBatchSize > 0 && len(h.batch.order) >= h.options.MaxBatchSize {
+		h.flushBatch()
+	}
+}
+
+// flushBatch publica o lote aberto. Um lote com um único item sai com o tipo
+// original do evento; com mais itens, sai como um array no tipo do lote.
+func (h *Hub) flushBatch() {
+	if h.batch == nil {
+		return
+	}
+	pending := h.batch
+	h.batch = nil
+	h.batchDeadline = nil
+
+	items := make([]json.RawMessage, 0, len(pending.order))
+	for _, key := range pending.order {
+		items = append(items, pending.items[key])
+	}
+	h.mergeItems(pending.collection, items...)
+
+	if len(items) == 1 {
+		h.publish(Event{Type: pending.eventType, Data: items[0]})
+		return
+	}
+	data, err := json.Marshal(items)
 	if err != nil {
-		log.Printf("Erro ao serializar mensagem: %v", err)
+		h.logger.Error("Erro ao serializar lote", "error", err)
 		return
 	}
-	h.broadcast <- jsonMessage
+	h.batched.Add(uint64(len(items) - 1))
+	h.publish(Event{Type: pending.batchType, Data: data})
+}
+
+// syncDocument compara state com o último documento enviado e, se houver
+// diferença, publica um patch (ou a sessão completa, para clientes que pediram)
+// e avança a versão do documento
+func (h *Hub) syncDocument(state interface{}) {
+	// As listas mantidas pelos eventos de item seguem como estão no documento:
+	// o estado recebido pode ser anterior a um evento de item já publicado, e
+	// o cliente já aplicou esses eventos
+	if current, ok := h.document.(map[string]interface{}); ok {
+		if next, ok := state.(map[string]interface{}); ok {
+			for collection := range h.collections {
+				if items, exists := current[collection]; exists {
+					next[collection] = items
+				}
+			}
+		}
+	}
+
+	ops := jsonpatch.Diff(h.document, state)
+	if h.documentVersion > 0 && len(ops) == 0 {
+		return
+	}
+
+	patch := SessionPatch{
+		BaseVersion: h.documentVersion,
+		Version:     h.documentVersion + 1,
+		Ops:         ops,
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		h.logger.Error("Erro ao serializar patch", "error", err)
+		return
+	}
+	full, err := json.Marshal(state)
+	if err != nil {
+		h.logger.Error("Erro ao serializar sessão", "error", err)
+		return
+	}
+
+	h.document = state
+	h.documentVersion = patch.Version
+	h.publish(Event{Type: EventSessionPatch, Data: data, fullType: EventSessionUpdate, fullData: full})
+}
+
+// mergeItems aplica itens publicados por BroadcastBatched à lista collection do
+// documento: cada item substitui o de mesmo "id" ou é acrescentado ao fim, como
+// o cliente faz ao receber o evento. A partir daí a lista pertence aos eventos
+// de item e deixa de aparecer nos patches.
+func (h *Hub) mergeItems(collection string, items ...json.RawMessage) {
+	if collection == "" {
+		return
+	}
+	if h.collections == nil {
+		h.collections = make(map[string]bool)
+	}
+	h.collections[collection] = true
+
+	document, ok := h.document.(map[string]interface{})
+	if !ok {
+		return
+	}
+	list, _ := document[collection].([]interface{})
+	list = append([]interface{}(nil), list...)
+	for _, raw := range items {
+		var item map[string]interface{}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			h.logger.Error("Erro ao decodificar item", "error", err)
+			continue
+		}
+		replaced := false
+		for i, existing := range list {
+			if current, ok := existing.(map[string]interface{}); ok && current["id"] == item["id"] {
+				list[i] = item
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, item)
+		}
+	}
+	document[collection] = list
+}
+
+// publish numera o evento, guarda no histórico e entrega a todos os clientes
+func (h *Hub) publish(event Event) {
+	h.seq++
+	event.Seq = h.seq
+	message, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Erro ao serializar evento", "error", err)
+		return
+	}
+	entry := outbound{seq: event.Seq, message: message}
+	if event.fullType != "" {
+		entry.full, err = json.Marshal(Event{Seq: event.Seq, Type: event.fullType, Data: event.fullData})
+		if err != nil {
+			h.logger.Error("Erro ao serializar evento", "error", err)
+			return
+		}
+	}
+
+	h.published.Add(1)
+	h.remember(entry)
+	for client := range h.clients {
+		h.deliver(client, entry)
+	}
+}
+
+// remember guarda a mensagem no buffer circular de replay
+func (h *Hub) remember(entry outbound) {
+	if h.options.HistorySize <= 0 {
+		return
+	}
+	if len(h.history) == h.options.HistorySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:h.options.HistorySize-1]
+	}
+	h.history = append(h.history, entry)
+}
+
+// snapshotMessage sincroniza o documento da sessão com o estado atual e
+// serializa o snapshot com a sequência e a versão resultantes
+func (h *Hub) snapshotMessage() ([]byte, bool) {
+	if h.snapshot == nil {
+		return nil, false
+	}
+
+	state, err := h.snapshot()
+	if err != nil {
+		h.logger.Error("Erro ao gerar snapshot", "error", err)
+		return nil, false
+	}
+
+	raw, err := json.Marshal(state)
+	if err != nil {
+		h.logger.Error("Erro ao serializar snapshot", "error", err)
+		return nil, false
+	}
+	var document interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		h.logger.Error("Erro ao serializar snapshot", "error", err)
+		return nil, false
+	}
+	h.syncDocument(document)
+
+	data, err := json.Marshal(Snapshot{
+		Session:  h.document,
+		Version:  h.documentVersion,
+		Stream:   h.stream,
+		Presence: Presence{Connected: len(h.clients)},
+	})
+	if err != nil {
+		h.logger.Error("Erro ao serializar snapshot", "error", err)
+		return nil, false
+	}
+
+	message, err := json.Marshal(Event{Seq: h.seq, Type: EventSnapshot, Data: data})
+	if err != nil {
+		h.logger.Error("Erro ao serializar snapshot", "error", err)
+		return nil, false
+	}
+	return message, true
+}
+
+// deliver enfileira a mensagem para o cliente. Se a fila estiver cheia, aplica
+// a política de consumidor lento configurada.
+func (h *Hub) deliver(client *Client, entry outbound) {
+	if _, ok := h.clients[client]; !ok || client.stale {
+		return
+	}
+	message := entry.messageFor(client)
+	select {
+	case client.send <- message:
+		return
+	default:
+	}
+
+	switch h.options.SlowConsumerPolicy {
+	case PolicyDropOldest:
+		// A mensagem descartada deixa um buraco na sequência do cliente: ele
+		// recebe o que restou na fila e, no lugar desta e das seguintes, um
+		// snapshot enviado por refreshStale ao fim do ciclo de Run
+		select {
+		case <-client.send:
+			h.dropped.Add(1)
+		default:
+		}
+		h.dropped.Add(1)
+		client.stale = true
+		h.hasStale = true
+
+	case PolicyCoalesce:
+		// Tudo o que está na fila é substituído por um único snapshot atual,
+		// enviado por refreshStale ao fim do ciclo de Run
+		for drained := false; !drained; {
+			select {
+			case <-client.send:
+				h.coalesced.Add(1)
+			default:
+				drained = true
+			}
+		}
+		h.coalesced.Add(1)
+		client.stale = true
+		h.hasStale = true
+
+	default:
+		h.disconnect(client, CloseSlowConsumer, "slow consumer")
+	}
+}
+
+// DisconnectUser fecha todas as conexões do usuário com o código e o motivo informados
+func (h *Hub) DisconnectUser(userID string, code int, reason string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for client := range h.clients {
+		if client.userID == userID {
+			h.disconnect(client, code, reason)
+		}
+	}
+}
+
+// disconnect remove o cliente e pede ao writePump que feche com o código informado
+func (h *Hub) disconnect(client *Client, code int, reason string) {
+	client.logger.Info("cliente desconectado pelo servidor", "close_code", code, "reason", reason)
+	client.closeCode = code
+	client.closeReason = reason
+	close(client.send)
+	h.remove(client)
+	h.disconnected.Add(1)
+}
+
+// remove tira o cliente do mapa e marca o início da ociosidade se ele era o último
+func (h *Hub) remove(client *Client) {
+	delete(h.clients, client)
+	if len(h.clients) == 0 {
+		h.emptySince = h.clock.Now()
+	}
+}
+
+// IdleFor retorna há quanto tempo o hub está sem clientes, ou zero se há algum conectado
+func (h *Hub) IdleFor() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.clients) > 0 {
+		return 0
+	}
+	return h.clock.Now().Sub(h.emptySince)
+}
+
+// Broadcast enfileira uma mensagem do tipo informado para todos os clientes
+// conectados. Nunca bloqueia: a entrega acontece na goroutine de Run.
+func (h *Hub) Broadcast(eventType string, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error("Erro ao serializar mensagem", "error", err)
+		return
+	}
+	h.push(Event{Type: eventType, Data: data})
+}
+
+// BroadcastBatched funciona como Broadcast, mas atualizações publicadas dentro
+// de BatchWindow são agrupadas: para cada key só a versão mais recente é
+// mantida, e o lote sai como uma mensagem do tipo batchType com um array.
+//
+// message é um item da lista collection do documento da sessão (por exemplo,
+// um card em "cards"), identificado pelo campo "id". O cliente substitui o item
+// de mesmo id ou o acrescenta; a lista passa a mudar apenas por esses eventos,
+// nunca por "session_patch", para que a mesma mudança não chegue duas vezes.
+func (h *Hub) BroadcastBatched(eventType, batchType, collection, key string, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error("Erro ao serializar mensagem", "error", err)
+		return
+	}
+	h.push(Event{Type: eventType, Data: data, batchType: batchType, key: key, collection: collection})
+}
+
+// BroadcastDocument publica o novo estado completo da sessão. Clientes recebem
+// um "session_patch" contra a versão anterior do documento, ou um
+// "session_update" com a sessão completa se negociaram SubprotocolFull.
+// version é a versão do estado na origem: um estado lido antes de outro já
+// publicado é descartado, para que leituras concorrentes não desfaçam mudanças.
+func (h *Hub) BroadcastDocument(version uint64, state interface{}) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		h.logger.Error("Erro ao serializar mensagem", "error", err)
+		return
+	}
+	h.push(Event{Type: EventSessionUpdate, Data: data, document: true, stateVersion: version})
+}
+
+// push acrescenta o evento à fila de entrada e acorda Run
+func (h *Hub) push(event Event) {
+	h.pendingMutex.Lock()
+	h.pending = append(h.pending, event)
+	h.pendingMutex.Unlock()
+
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stats retorna os contadores do hub
+func (h *Hub) Stats() HubStats {
+	h.mutex.Lock()
+	clients := len(h.clients)
+	h.mutex.Unlock()
+
+	return HubStats{
+		Clients:      clients,
+		Published:    h.published.Load(),
+		Dropped:      h.dropped.Load(),
+		Coalesced:    h.coalesced.Load(),
+		Disconnected: h.disconnected.Load(),
+		Batched:      h.batched.Load(),
+	}
 }
 
 // Clients retorna todos os clientes conectados
 func (h *Hub) Clients() map[*Client]bool {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	
+
 	// Criar uma cópia do mapa para evitar problemas de concorrência
 	clientsCopy := make(map[*Client]bool)
 	for client := range h.clients {
@@ -88,5 +760,60 @@ func (h *Hub) Clients() map[*Client]bool {
 
 // Unregister remove um cliente do hub
 func (h *Hub) Unregister(client *Client) {
-	h.unregister <- client
-} 
\ No newline at end of file
+	select {
+	case h.unregister <- client:
+	case <-h.done:
+	}
+}
+
+// Stop pede que Run entregue os broadcasts pendentes, feche todas as conexões
+// com o código e o motivo informados e retorne. Chamadas repetidas são ignoradas.
+func (h *Hub) Stop(code int, reason string) {
+	h.stopOnce.Do(func() {
+		h.mutex.Lock()
+		h.stopping = true
+		h.stopCode = code
+		h.stopReason = reason
+		h.mutex.Unlock()
+		close(h.stop)
+	})
+}
+
+// Done é fechado quando Run retorna
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait aguarda Run retornar e as conexões terminarem de escrever o que estava
+// na fila, ou o fim de ctx
+func (h *Hub) Wait(ctx context.Context) error {
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track conta uma nova conexão em writers, a menos que o hub esteja parando
+func (h *Hub) track() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.stopping {
+		return false
+	}
+	h.writers.Add(1)
+	return true
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"flash-cards/backend/internal/clock"
+	"flash-cards/backend/internal/random"
+)
+
+func TestCanReplay(t *testing.T) {
+	hub := &Hub{
+		stream:  "current",
+		seq:     10,
+		history: []outbound{{seq: 8}, {seq: 9}, {seq: 10}},
+	}
+
+	tests := []struct {
+		name    string
+		stream  string
+		lastSeq uint64
+		want    bool
+	}{
+		{"up to date", "current", 10, true},
+		{"missed events in history", "current", 7, true},
+		{"missed events evicted", "current", 6, false},
+		{"ahead of the hub", "current", 11, false},
+		{"other stream", "previous", 10, false},
+		{"other stream behind the hub", "previous", 9, false},
+		{"no stream, in history", "", 9, true},
+		{"no stream, ahead of the hub", "", 42, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := hub.canReplay(test.stream, test.lastSeq); got != test.want {
+				t.Fatalf("canReplay(%q, %d) = %v, want %v", test.stream, test.lastSeq, got, test.want)
+			}
+		})
+	}
+}
+
+func TestStaleSessionDoesNotRevertCards(t *testing.T) {
+	hub := NewHub(nil, HubOptions{HistorySize: 10}, clock.System, random.NewIDGenerator(random.NewSeeded(1)),
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	decode := func(raw string) interface{} {
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	hub.syncDocument(decode(`{"state":"OPEN","cards":[{"id":"c1","votes":[]}]}`))
+	hub.mergeItems("cards", json.RawMessage(`{"id":"c1","votes":[3]}`))
+
+	// O estado lido antes do voto chega depois do card_update dele
+	hub.syncDocument(decode(`{"state":"CLOSED","cards":[{"id":"c1","votes":[]}]}`))
+
+	var event Event
+	if err := json.Unmarshal(hub.history[len(hub.history)-1].message, &event); err != nil {
+		t.Fatal(err)
+	}
+	var patch SessionPatch
+	if err := json.Unmarshal(event.Data, &patch); err != nil {
+		t.Fatal(err)
+	}
+	if len(patch.Ops) != 1 || patch.Ops[0].Path != "/state" {
+		t.Fatalf("expected only the state change, got %+v", patch.Ops)
+	}
+	cards := hub.document.(map[string]interface{})["cards"].([]interface{})
+	if votes := cards[0].(map[string]interface{})["votes"].([]interface{}); len(votes) != 1 {
+		t.Fatalf("card was reverted to %v", cards[0])
+	}
+}
+
+func TestOlderDocumentIsDropped(t *testing.T) {
+	hub := NewHub(nil, HubOptions{HistorySize: 10}, clock.System, random.NewIDGenerator(random.NewSeeded(1)),
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	document := func(version uint64, raw string) Event {
+		return Event{Type: EventSessionUpdate, Data: json.RawMessage(raw), document: true, stateVersion: version}
+	}
+	hub.enqueue(document(1, `{"users":["ana"],"version":1}`))
+	hub.enqueue(document(3, `{"users":["ana","bia","caio"],"version":3}`))
+	// Lido antes da entrada de caio, chega depois dela
+	hub.enqueue(document(2, `{"users":["ana","bia"],"version":2}`))
+
+	if hub.documentVersion != 2 || len(hub.history) != 2 {
+		t.Fatalf("expected 2 patches, got version %d and %d events", hub.documentVersion, len(hub.history))
+	}
+	users := hub.document.(map[string]interface{})["users"].([]interface{})
+	if len(users) != 3 {
+		t.Fatalf("older document reverted the participants to %v", users)
+	}
+}
+
+func TestDropOldestResyncsWithSnapshot(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	snapshot := func() (interface{}, error) { return map[string]interface{}{"state": "OPEN"}, nil }
+	hub := NewHub(snapshot, HubOptions{HistorySize: 10, SlowConsumerPolicy: PolicyDropOldest}, clock.System,
+		random.NewIDGenerator(random.NewSeeded(1)), logger)
+	client := &Client{hub: hub, send: make(chan []byte, 2), logger: logger}
+	hub.clients[client] = true
+
+	// A fila comporta 2: o terceiro evento descarta o primeiro
+	for i := 0; i < 4; i++ {
+		hub.publish(Event{Type: "card_update", Data: json.RawMessage(`{}`)})
+	}
+	hub.refreshStale()
+
+	var received []Event
+	for len(client.send) > 0 {
+		var event Event
+		if err := json.Unmarshal(<-client.send, &event); err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, event)
+	}
+	// O snapshot cobre tudo até o seq atual do hub, inclusive os eventos descartados
+	if len(received) != 2 || received[0].Seq != 2 || received[1].Type != EventSnapshot || received[1].Seq != hub.seq {
+		t.Fatalf("expected event 2 and a snapshot at seq %d, got %+v", hub.seq, received)
+	}
+	if _, connected := hub.clients[client]; !connected || client.stale {
+		t.Fatal("client should stay connected and in sync after the snapshot")
+	}
+	if dropped := hub.Stats().Dropped; dropped != 2 {
+		t.Fatalf("expected 2 dropped messages, got %d", dropped)
+	}
+}
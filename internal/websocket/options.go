@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlowConsumerPolicy define o que o hub faz quando o buffer de envio de um cliente enche
+type SlowConsumerPolicy string
+
+const (
+	// PolicyDropOldest descarta a mensagem mais antiga da fila do cliente e o
+	// ressincroniza com um snapshot depois das que restaram
+	PolicyDropOldest SlowConsumerPolicy = "drop_oldest"
+	// PolicyCoalesce descarta a fila inteira e envia um snapshot com o estado mais recente
+	PolicyCoalesce SlowConsumerPolicy = "coalesce"
+	// PolicyDisconnect fecha a conexão com CloseSlowConsumer
+	PolicyDisconnect SlowConsumerPolicy = "disconnect"
+)
+
+// Códigos de fechamento enviados quando o hub encerra uma conexão
+const (
+	// CloseRevoked indica que o token do participante foi revogado (saída ou remoção)
+	CloseRevoked = 4003
+	// CloseSlowConsumer indica que o cliente lento foi desconectado
+	CloseSlowConsumer = 4008
+	// CloseServiceRestart (1012, RFC 6455) indica que o servidor está reiniciando
+	CloseServiceRestart = 1012
+	// CloseGoingAway (1001, RFC 6455) indica que o hub foi encerrado por ociosidade
+	CloseGoingAway = 1001
+	// CloseSessionClosed indica que a sessão foi encerrada
+	CloseSessionClosed = 4010
+)
+
+// HubOptions agrupa os parâmetros de um Hub
+type HubOptions struct {
+	// SendBufferSize é a capacidade da fila de saída de cada cliente
+	SendBufferSize int
+	// HistorySize é a quantidade de eventos mantidos para replay em reconexões
+	HistorySize int
+	// SlowConsumerPolicy é aplicada quando a fila de um cliente está cheia
+	SlowConsumerPolicy SlowConsumerPolicy
+	// BatchWindow é o tempo em que atualizações agrupáveis são acumuladas antes
+	// de serem enviadas como uma única mensagem. Zero desativa o agrupamento.
+	BatchWindow time.Duration
+	// MaxBatchSize força o envio do lote ao atingir esse número de itens distintos
+	MaxBatchSize int
+	// IdleTimeout é quanto tempo um hub sem clientes continua ativo antes de ser
+	// encerrado. Zero mantém hubs ociosos até a sessão ser encerrada.
+	IdleTimeout time.Duration
+
+	// WriteWait é o prazo para escrever uma mensagem na conexão
+	WriteWait time.Duration
+	// PongWait é quanto tempo uma conexão pode ficar sem responder ao ping; os
+	// pings são enviados a cada 9/10 desse intervalo
+	PongWait time.Duration
+	// MaxMessageSize é o tamanho máximo, em bytes, de uma mensagem recebida do cliente
+	MaxMessageSize int64
+	// ReadBufferSize e WriteBufferSize são os buffers de I/O de cada conexão
+	ReadBufferSize  int
+	WriteBufferSize int
+	// AllowedOrigins restringe o header Origin aceito no upgrade; "*" ou a lista
+	// vazia aceitam qualquer origem. Requisições sem Origin são sempre aceitas.
+	AllowedOrigins []string
+}
+
+// DefaultHubOptions retorna as opções padrão do hub
+func DefaultHubOptions() HubOptions {
+	return HubOptions{
+		SendBufferSize:     256,
+		HistorySize:        128,
+		SlowConsumerPolicy: PolicyCoalesce,
+		BatchWindow:        50 * time.Millisecond,
+		MaxBatchSize:       100,
+		IdleTimeout:        5 * time.Minute,
+		WriteWait:          10 * time.Second,
+		PongWait:           60 * time.Second,
+		MaxMessageSize:     512,
+		ReadBufferSize:     1024,
+		WriteBufferSize:    1024,
+	}
+}
+
+// Validate confere que as opções formam uma configuração utilizável
+func (o HubOptions) Validate() error {
+	if _, err := ParseSlowConsumerPolicy(string(o.SlowConsumerPolicy)); err != nil {
+		return err
+	}
+	switch {
+	case o.SendBufferSize < 1:
+		return fmt.Errorf("send buffer size must be positive, got %d", o.SendBufferSize)
+	case o.HistorySize < 0:
+		return fmt.Errorf("history size must not be negative, got %d", o.HistorySize)
+	case o.BatchWindow < 0:
+		return fmt.Errorf("batch window must not be negative, got %s", o.BatchWindow)
+	case o.BatchWindow > 0 && o.MaxBatchSize < 1:
+		return fmt.Errorf("max batch size must be positive when batching, got %d", o.MaxBatchSize)
+	case o.IdleTimeout < 0:
+		return fmt.Errorf("idle timeout must not be negative, got %s", o.IdleTimeout)
+	case o.WriteWait <= 0:
+		return fmt.Errorf("write wait must be positive, got %s", o.WriteWait)
+	case o.PongWait < time.Second:
+		return fmt.Errorf("pong wait must be at least 1s, got %s", o.PongWait)
+	case o.MaxMessageSize < 1:
+		return fmt.Errorf("max message size must be positive, got %d", o.MaxMessageSize)
+	case o.ReadBufferSize < 1 || o.WriteBufferSize < 1:
+		return fmt.Errorf("read and write buffer sizes must be positive")
+	}
+	return nil
+}
+
+// ParseSlowConsumerPolicy converte o nome de uma política, retornando erro se for desconhecida
+func ParseSlowConsumerPolicy(name string) (SlowConsumerPolicy, error) {
+	switch policy := SlowConsumerPolicy(name); policy {
+	case PolicyDropOldest, PolicyCoalesce, PolicyDisconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown slow consumer policy %q", name)
+	}
+}
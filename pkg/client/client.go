@@ -0,0 +1,205 @@
+// Package client é o SDK Go da API de planning poker: um método por endpoint
+// REST e um assinante do WebSocket que decodifica os eventos, reconecta e
+// retoma do último evento recebido.
+//
+// Uso típico, inclusive contra um httptest.Server:
+//
+//	c := client.New(server.URL, client.WithHTTPClient(server.Client()))
+//	created, err := c.CreateSession(ctx, "Ana", "")
+//	owner := c.WithToken(created.Token)
+//	card, err := owner.CreateCard(ctx, created.Code, "Login", "")
+//	sub, err := owner.Subscribe(ctx, created.Code, client.SubscribeOptions{})
+//	for event := range sub.Events { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIPrefix é o prefixo da versão da API usada pelo cliente
+const APIPrefix = "/api/v1"
+
+// Client chama a API em nome de um participante. É imutável: WithToken e
+// WithLanguage retornam cópias, então um mesmo Client pode ser compartilhado
+// entre goroutines.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	language   string
+}
+
+// Option configura um Client
+type Option func(*Client)
+
+// WithHTTPClient usa o http.Client informado, como o de um httptest.Server
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithToken autentica as chamadas com o token de um participante
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithLanguage pede as mensagens de erro no idioma informado (Accept-Language)
+func WithLanguage(language string) Option {
+	return func(c *Client) { c.language = language }
+}
+
+// New cria um cliente para o servidor em baseURL, como "http://localhost:3001"
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// WithToken retorna uma cópia do cliente autenticada com token
+func (c *Client) WithToken(token string) *Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// WithLanguage retorna uma cópia do cliente que pede mensagens no idioma informado
+func (c *Client) WithLanguage(language string) *Client {
+	clone := *c
+	clone.language = language
+	return &clone
+}
+
+// Token retorna o token usado pelo cliente
+func (c *Client) Token() string {
+	return c.token
+}
+
+// Códigos de erro da API; a lista completa está no documento OpenAPI
+const (
+	CodeInvalidRequest  = "INVALID_REQUEST"
+	CodeNameTaken       = "NAME_TAKEN"
+	CodeSessionNotFound = "SESSION_NOT_FOUND"
+	CodeSessionClosed   = "SESSION_CLOSED"
+	CodeVotingClosed    = "VOTING_CLOSED"
+	CodeCardNotFound    = "CARD_NOT_FOUND"
+	CodeNotOwner        = "NOT_OWNER"
+	CodeNotFacilitator  = "NOT_FACILITATOR"
+	CodeTokenRevoked    = "TOKEN_REVOKED"
+	CodeTokenExpired    = "TOKEN_EXPIRED"
+	CodeVersionMismatch = "VERSION_MISMATCH"
+	CodeRateLimited     = "RATE_LIMITED"
+	CodeShuttingDown    = "SERVER_RESTARTING"
+)
+
+// FieldError detalha um campo inválido de um INVALID_REQUEST
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError é uma resposta de erro da API
+type APIError struct {
+	Status  int          `json:"-"`
+	Code    string       `json:"code"`
+	Message string       `json:"error"`
+	Fields  []FieldError `json:"fields"`
+	// RetryAfter vem do header Retry-After, em respostas 429 e 503
+	RetryAfter int `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+	for _, field := range e.Fields {
+		message += fmt.Sprintf("; %s %s", field.Field, field.Message)
+	}
+	return message
+}
+
+// IsCode informa se err é um APIError com o código informado
+func IsCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+type ifMatchKey struct{}
+
+// IfMatch retorna um contexto que envia If-Match com a versão da sessão nas
+// chamadas que alteram a sessão; a chamada falha com VERSION_MISMATCH se a
+// sessão mudou desde então
+func IfMatch(ctx context.Context, version uint64) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, version)
+}
+
+// do envia a requisição e decodifica a resposta em out, se não for nil.
+// Respostas fora de 2xx viram *APIError.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+APIPrefix+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if version, ok := ctx.Value(ifMatchKey{}).(uint64); ok {
+		req.Header.Set("If-Match", `"`+strconv.FormatUint(version, 10)+`"`)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, decodeError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("decoding %s %s response: %w", method, path, err)
+		}
+	}
+	return resp, nil
+}
+
+// decodeError lê o corpo de erro da API; corpos inesperados viram a mensagem
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode}
+	apiErr.RetryAfter, _ = strconv.Atoi(resp.Header.Get("Retry-After"))
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Code == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	return apiErr
+}
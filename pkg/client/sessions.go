@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Os métodos abaixo seguem as operações do documento OpenAPI. Os que alteram a
+// sessão aceitam um contexto criado por IfMatch.
+
+func sessionPath(code string, parts ...string) string {
+	path := "/sessions/" + url.PathEscape(code)
+	for _, part := range parts {
+		path += "/" + part
+	}
+	return path
+}
+
+// CreateSession cria uma sessão; code é um código personalizado opcional,
+// aceito apenas se o servidor permitir
+func (c *Client) CreateSession(ctx context.Context, ownerName, code string) (*CreatedSession, error) {
+	body := map[string]string{"ownerName": ownerName}
+	if code != "" {
+		body["code"] = code
+	}
+
+	var created CreatedSession
+	if _, err := c.do(ctx, http.MethodPost, "/sessions", body, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// JoinSession entra na sessão como convidado
+func (c *Client) JoinSession(ctx context.Context, code, userName string) (*Participant, error) {
+	var participant Participant
+	if _, err := c.do(ctx, http.MethodPost, sessionPath(code, "join"), map[string]string{"userName": userName}, &participant); err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+// GetSession retorna a sessão com seus cards; Version serve para IfMatch
+func (c *Client) GetSession(ctx context.Context, code string) (*Session, error) {
+	var session Session
+	if _, err := c.do(ctx, http.MethodGet, sessionPath(code), nil, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SetSessionState abre ou fecha a sessão; apenas o owner pode fazê-lo
+func (c *Client) SetSessionState(ctx context.Context, code string, state SessionState) error {
+	_, err := c.do(ctx, http.MethodPut, sessionPath(code, "state"), map[string]SessionState{"state": state}, nil)
+	return err
+}
+
+// CloseSession fecha a sessão
+func (c *Client) CloseSession(ctx context.Context, code string) error {
+	return c.SetSessionState(ctx, code, SessionClosed)
+}
+
+// LeaveSession sai da sessão e invalida o token do cliente. A saída do owner
+// fecha a sessão.
+func (c *Client) LeaveSession(ctx context.Context, code string) error {
+	_, err := c.do(ctx, http.MethodPost, sessionPath(code, "leave"), nil, nil)
+	return err
+}
+
+// ListCards retorna os cards da sessão, na ordem de criação
+func (c *Client) ListCards(ctx context.Context, code string) ([]Card, error) {
+	var cards []Card
+	if _, err := c.do(ctx, http.MethodGet, sessionPath(code, "cards"), nil, &cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// MyCards retorna os cards da sessão do token, sem precisar do código
+func (c *Client) MyCards(ctx context.Context) ([]Card, error) {
+	var cards []Card
+	if _, err := c.do(ctx, http.MethodGet, "/cards", nil, &cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// CreateCard cria um card; apenas o owner pode fazê-lo
+func (c *Client) CreateCard(ctx context.Context, code, title, description string) (*Card, error) {
+	body := map[string]string{"title": title, "description": description}
+
+	var card Card
+	if _, err := c.do(ctx, http.MethodPost, sessionPath(code, "cards"), body, &card); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Vote registra um voto no card
+func (c *Client) Vote(ctx context.Context, code, cardID string, score int) (*Card, error) {
+	var card Card
+	if _, err := c.do(ctx, http.MethodPost, sessionPath(code, "cards", url.PathEscape(cardID), "vote"), map[string]int{"score": score}, &card); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Reveal encerra a votação do card e retorna o resultado; apenas o owner ou um
+// facilitador pode fazê-lo
+func (c *Client) Reveal(ctx context.Context, code, cardID string) (*Card, error) {
+	var card Card
+	if _, err := c.do(ctx, http.MethodPost, sessionPath(code, "cards", url.PathEscape(cardID), "close"), nil, &card); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// ResetVotes apaga os votos e reabre todos os cards da sessão
+func (c *Client) ResetVotes(ctx context.Context, code string) ([]Card, error) {
+	var cards []Card
+	if _, err := c.do(ctx, http.MethodPost, sessionPath(code, "reset-votes"), nil, &cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// KickUser remove um participante da sessão; apenas o owner pode fazê-lo
+func (c *Client) KickUser(ctx context.Context, code, userID string) error {
+	_, err := c.do(ctx, http.MethodDelete, sessionPath(code, "users", url.PathEscape(userID)), nil, nil)
+	return err
+}
+
+// SetRole promove um participante a facilitador ou o devolve a convidado
+func (c *Client) SetRole(ctx context.Context, code, userID string, role Role) (*User, error) {
+	var user User
+	if _, err := c.do(ctx, http.MethodPut, sessionPath(code, "users", url.PathEscape(userID), "role"), map[string]Role{"role": role}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Export escreve em out a sessão com participantes, cards, votos e resultados,
+// como JSON indentado. A API não tem um endpoint de exportação; o conteúdo é o
+// de GetSession.
+func (c *Client) Export(ctx context.Context, code string, out io.Writer) error {
+	session, err := c.GetSession(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(session)
+}
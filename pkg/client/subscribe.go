@@ -0,0 +1,335 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocolos do WebSocket; ver SubscribeOptions.Full
+const (
+	subprotocolPatch = "poker.patch"
+	subprotocolFull  = "poker.full"
+)
+
+// Códigos de fechamento enviados pelo servidor
+const (
+	CloseRevoked        = 4003
+	CloseSlowConsumer   = 4008
+	CloseSessionClosed  = 4010
+	CloseServiceRestart = websocket.CloseServiceRestart
+)
+
+// SubscribeOptions configura uma assinatura do WebSocket
+type SubscribeOptions struct {
+	// Full pede "session_update" com a sessão completa em vez de "session_patch"
+	Full bool
+	// LastSeq retoma a partir de um evento já recebido; zero começa com snapshot.
+	// Stream é o Snapshot.Stream em que LastSeq foi recebido; sem ele, o servidor
+	// só detecta que o hub foi recriado se LastSeq estiver à frente do hub.
+	LastSeq uint64
+	Stream  string
+	// ReconnectDelay é a espera antes da primeira reconexão; dobra a cada falha
+	// seguida até MaxReconnectDelay. Padrão: 250ms e 10s.
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	// Buffer é a capacidade do canal Events. Padrão: 64.
+	Buffer int
+	// Dialer substitui o websocket.DefaultDialer, por exemplo em testes
+	Dialer *websocket.Dialer
+}
+
+// Subscription entrega os eventos de uma sessão em Events, reconectando e
+// retomando do último seq recebido quando a conexão cai ou o servidor reinicia.
+// Cada snapshot recomeça a contagem a partir do seq dele, já que o hub pode ter
+// sido recriado com uma nova sequência. Um seq pulado ou um patch que não parte
+// da versão já recebida não é entregue: a assinatura reconecta para receber o
+// replay do que faltou ou um novo snapshot.
+// Events é fechado quando o contexto acaba, Close é chamado ou a assinatura
+// termina por um erro definitivo, que Err então retorna.
+type Subscription struct {
+	Events <-chan Event
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex   sync.Mutex
+	err     error
+	lastSeq uint64
+	stream  string
+	// version é a versão do documento da sessão depois do último snapshot ou
+	// patch entregue; resync pede um snapshot na próxima conexão
+	version uint64
+	resync  bool
+}
+
+// Subscribe conecta ao WebSocket da sessão com o token do cliente. A primeira
+// conexão é feita antes de retornar, então erros de autenticação aparecem aqui.
+func (c *Client) Subscribe(ctx context.Context, code string, opts SubscribeOptions) (*Subscription, error) {
+	if opts.ReconnectDelay <= 0 {
+		opts.ReconnectDelay = 250 * time.Millisecond
+	}
+	if opts.MaxReconnectDelay < opts.ReconnectDelay {
+		opts.MaxReconnectDelay = 10 * time.Second
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 64
+	}
+	if opts.Dialer == nil {
+		opts.Dialer = websocket.DefaultDialer
+	}
+
+	conn, err := c.dial(ctx, code, opts, opts.LastSeq, opts.Stream)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan Event, opts.Buffer)
+	sub := &Subscription{
+		Events:  events,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		lastSeq: opts.LastSeq,
+		stream:  opts.Stream,
+	}
+	go sub.run(ctx, c, code, opts, conn, events)
+	return sub, nil
+}
+
+// Close encerra a assinatura e aguarda o fechamento de Events
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// Err retorna o erro que encerrou a assinatura, ou nil se ela foi encerrada
+// pelo contexto ou por Close
+func (s *Subscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// LastSeq retorna o seq do último evento entregue
+func (s *Subscription) LastSeq() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastSeq
+}
+
+// Stream retorna o Snapshot.Stream do último snapshot entregue; junto com
+// LastSeq, permite retomar a sessão numa nova assinatura
+func (s *Subscription) Stream() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stream
+}
+
+// position retorna de onde retomar; zero pede um snapshot
+func (s *Subscription) position() (uint64, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.resync {
+		return 0, ""
+	}
+	return s.lastSeq, s.stream
+}
+
+// accept confere se o evento continua a sequência já entregue e avança a
+// posição da assinatura. Retorna false se faltou algum evento antes dele.
+func (s *Subscription) accept(event Event) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if event.Type == EventSnapshot {
+		// O snapshot define o ponto de partida, mesmo que o seq dele seja menor
+		// que o anterior: o hub foi recriado e a sequência recomeçou
+		s.lastSeq = event.Seq
+		s.resync = false
+		if snapshot, err := event.Snapshot(); err == nil {
+			s.stream = snapshot.Stream
+			s.version = snapshot.Version
+		}
+		return true
+	}
+	if event.Seq <= s.lastSeq {
+		return true
+	}
+	if s.lastSeq > 0 && event.Seq != s.lastSeq+1 {
+		return false
+	}
+
+	if event.Type == EventSessionPatch {
+		if patch, err := event.Patch(); err == nil {
+			// Sem a versão de base o documento do cliente divergiu; o replay
+			// repetiria o mesmo patch, então só um snapshot resolve
+			if s.version > 0 && patch.BaseVersion != s.version {
+				s.resync = true
+				return false
+			}
+			s.version = patch.Version
+		}
+	}
+	s.lastSeq = event.Seq
+	return true
+}
+
+func (s *Subscription) run(ctx context.Context, c *Client, code string, opts SubscribeOptions, conn *websocket.Conn, events chan<- Event) {
+	defer close(s.done)
+	defer close(events)
+
+	delay := opts.ReconnectDelay
+	for {
+		wait, err := s.read(ctx, conn, events)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		for {
+			if wait == 0 {
+				wait = delay
+				delay = min(delay*2, opts.MaxReconnectDelay)
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+
+			lastSeq, stream := s.position()
+			conn, err = c.dial(ctx, code, opts, lastSeq, stream)
+			if err == nil {
+				delay = opts.ReconnectDelay
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if !retryable(err) {
+				s.fail(err)
+				return
+			}
+			wait = retryAfter(err)
+		}
+	}
+}
+
+// read entrega os eventos da conexão até ela cair. Retorna o erro que encerra a
+// assinatura ou, se ela deve reconectar, a espera pedida pelo servidor (zero
+// para usar o backoff).
+func (s *Subscription) read(ctx context.Context, conn *websocket.Conn, events chan<- Event) (time.Duration, error) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	var wait time.Duration
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				switch closeErr.Code {
+				case CloseRevoked, CloseSessionClosed:
+					return 0, closeErr
+				}
+			}
+			return wait, nil
+		}
+
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return 0, err
+		}
+		if event.Type == EventServerRestarting {
+			if notice, err := event.ServerRestarting(); err == nil {
+				wait = time.Duration(notice.ReconnectAfterMs) * time.Millisecond
+			}
+		}
+		if !s.accept(event) {
+			return wait, nil
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return 0, nil
+		}
+	}
+}
+
+func (s *Subscription) fail(err error) {
+	s.mutex.Lock()
+	s.err = err
+	s.mutex.Unlock()
+}
+
+// dial abre a conexão; respostas HTTP de erro no handshake viram *APIError
+func (c *Client) dial(ctx context.Context, code string, opts SubscribeOptions, lastSeq uint64, stream string) (*websocket.Conn, error) {
+	endpoint, err := url.Parse(c.baseURL + APIPrefix + "/ws/" + url.PathEscape(code))
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Scheme = strings.Replace(endpoint.Scheme, "http", "ws", 1)
+
+	query := url.Values{}
+	query.Set("token", c.token)
+	if lastSeq > 0 {
+		query.Set("lastSeq", strconv.FormatUint(lastSeq, 10))
+		if stream != "" {
+			query.Set("stream", stream)
+		}
+	}
+	endpoint.RawQuery = query.Encode()
+
+	dialer := *opts.Dialer
+	dialer.Subprotocols = []string{subprotocolPatch}
+	if opts.Full {
+		dialer.Subprotocols = []string{subprotocolFull}
+	}
+
+	header := http.Header{}
+	if c.language != "" {
+		header.Set("Accept-Language", c.language)
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, endpoint.String(), header)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// retryable informa se uma falha de reconexão é passageira: erros de rede,
+// limite de requisições e servidor reiniciando
+func retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.Status == http.StatusTooManyRequests || apiErr.Status >= 500
+}
+
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return time.Duration(apiErr.RetryAfter) * time.Second
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// scriptedServer responde a cada conexão com a próxima lista de mensagens e
+// guarda o lastSeq pedido em cada uma
+type scriptedServer struct {
+	scripts [][]string
+	mutex   sync.Mutex
+	dials   []string
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{subprotocolPatch}}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s.mutex.Lock()
+	index := len(s.dials)
+	s.dials = append(s.dials, r.URL.Query().Get("lastSeq"))
+	s.mutex.Unlock()
+
+	if index < len(s.scripts) {
+		for _, message := range s.scripts[index] {
+			conn.WriteMessage(websocket.TextMessage, []byte(message))
+		}
+	}
+	// Mantém a conexão aberta até o cliente fechá-la
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestSubscriptionResyncs(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts [][]string
+		// want são os seqs entregues; dials, o lastSeq de cada conexão
+		want  []uint64
+		dials []string
+	}{
+		{
+			name: "seq gap resumes from the last event",
+			scripts: [][]string{
+				{`{"seq":1,"type":"snapshot","data":{"version":1,"stream":"a"}}`, `{"seq":3,"type":"card_update","data":{}}`},
+				{`{"seq":2,"type":"card_update","data":{}}`, `{"seq":3,"type":"card_update","data":{}}`},
+			},
+			want:  []uint64{1, 2, 3},
+			dials: []string{"", "1"},
+		},
+		{
+			name: "patch on another base version asks for a snapshot",
+			scripts: [][]string{
+				{`{"seq":1,"type":"snapshot","data":{"version":1,"stream":"a"}}`,
+					`{"seq":2,"type":"session_patch","data":{"baseVersion":2,"version":3,"ops":[]}}`},
+				{`{"seq":2,"type":"snapshot","data":{"version":3,"stream":"a"}}`,
+					`{"seq":3,"type":"session_patch","data":{"baseVersion":3,"version":4,"ops":[]}}`},
+			},
+			want:  []uint64{1, 2, 3},
+			dials: []string{"", ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := &scriptedServer{scripts: test.scripts}
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			sub, err := New(httpServer.URL).WithToken("token").Subscribe(ctx, "ABCD12", SubscribeOptions{ReconnectDelay: time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sub.Close()
+
+			for i, want := range test.want {
+				event, ok := <-sub.Events
+				if !ok {
+					t.Fatalf("events closed after %d events: %v", i, sub.Err())
+				}
+				if event.Seq != want {
+					t.Fatalf("event %d has seq %d, want %d", i, event.Seq, want)
+				}
+			}
+
+			server.mutex.Lock()
+			defer server.mutex.Unlock()
+			if len(server.dials) != len(test.dials) {
+				t.Fatalf("expected dials %q, got %q", test.dials, server.dials)
+			}
+			for i := range test.dials {
+				if server.dials[i] != test.dials[i] {
+					t.Fatalf("expected dials %q, got %q", test.dials, server.dials)
+				}
+			}
+		})
+	}
+}
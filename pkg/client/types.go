@@ -0,0 +1,177 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Os tipos abaixo espelham o documento OpenAPI (/api/v1/openapi.json). Ficam
+// neste pacote, e não em internal/domain, para que programas fora do módulo
+// possam usá-los.
+
+type SessionState string
+
+const (
+	SessionOpen   SessionState = "OPEN"
+	SessionClosed SessionState = "CLOSED"
+)
+
+type Role string
+
+const (
+	RoleOwner       Role = "OWNER"
+	RoleFacilitator Role = "FACILITATOR"
+	RoleGuest       Role = "GUEST"
+)
+
+type Session struct {
+	ID        string       `json:"id"`
+	Code      string       `json:"code"`
+	CreatedAt time.Time    `json:"createdAt"`
+	State     SessionState `json:"state"`
+	OwnerID   string       `json:"ownerId"`
+	Cards     []Card       `json:"cards"`
+	Users     []User       `json:"users"`
+	Version   uint64       `json:"version"`
+}
+
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	JoinedAt  time.Time `json:"joinedAt"`
+	SessionID string    `json:"sessionId"`
+}
+
+type Card struct {
+	ID          string `json:"id"`
+	SessionID   string `json:"sessionId"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Votes       []int  `json:"votes"`
+	Result      Result `json:"result"`
+	Closed      bool   `json:"closed"`
+}
+
+type Result struct {
+	Average      float64     `json:"average"`
+	Distribution map[int]int `json:"distribution"`
+}
+
+// CreatedSession é a resposta da criação de uma sessão; Token é o do owner
+type CreatedSession struct {
+	Session Session `json:"session"`
+	Code    string  `json:"code"`
+	Token   string  `json:"token"`
+}
+
+// Participant é a resposta da entrada numa sessão
+type Participant struct {
+	User
+	Token string `json:"token"`
+}
+
+// Tipos de evento recebidos pelo WebSocket
+const (
+	EventSnapshot         = "snapshot"
+	EventSessionPatch     = "session_patch"
+	EventSessionUpdate    = "session_update"
+	EventCardUpdate       = "card_update"
+	EventCardsUpdated     = "cards_updated"
+	EventUserUpdate       = "user_update"
+	EventServerRestarting = "server_restarting"
+)
+
+// Event é o envelope de toda mensagem do WebSocket. Data é decodificado com os
+// métodos abaixo, de acordo com Type.
+type Event struct {
+	Seq  uint64          `json:"seq"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Snapshot traz o estado completo da sessão. Stream identifica a instância do
+// hub que numera os eventos seguintes; Subscription o usa ao retomar.
+type Snapshot struct {
+	Session  Session  `json:"session"`
+	Version  uint64   `json:"version"`
+	Stream   string   `json:"stream"`
+	Presence Presence `json:"presence"`
+}
+
+type Presence struct {
+	Connected int `json:"connected"`
+}
+
+// PatchOperation é uma operação JSON Patch (RFC 6902)
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type SessionPatch struct {
+	BaseVersion uint64           `json:"baseVersion"`
+	Version     uint64           `json:"version"`
+	Ops         []PatchOperation `json:"ops"`
+}
+
+// UserUpdate avisa que um participante entrou ("join"), saiu ("leave"), foi
+// removido ("kick") ou mudou de papel ("role")
+type UserUpdate struct {
+	Action string `json:"action"`
+	User   User   `json:"user"`
+}
+
+type ServerRestarting struct {
+	ReconnectAfterMs int64 `json:"reconnectAfterMs"`
+}
+
+// Snapshot decodifica um evento "snapshot"
+func (e Event) Snapshot() (Snapshot, error) {
+	var snapshot Snapshot
+	err := json.Unmarshal(e.Data, &snapshot)
+	return snapshot, err
+}
+
+// Session decodifica um evento "session_update"
+func (e Event) Session() (Session, error) {
+	var session Session
+	err := json.Unmarshal(e.Data, &session)
+	return session, err
+}
+
+// Patch decodifica um evento "session_patch"
+func (e Event) Patch() (SessionPatch, error) {
+	var patch SessionPatch
+	err := json.Unmarshal(e.Data, &patch)
+	return patch, err
+}
+
+// Card decodifica um evento "card_update"
+func (e Event) Card() (Card, error) {
+	var card Card
+	err := json.Unmarshal(e.Data, &card)
+	return card, err
+}
+
+// Cards decodifica um evento "cards_updated"
+func (e Event) Cards() ([]Card, error) {
+	var cards []Card
+	err := json.Unmarshal(e.Data, &cards)
+	return cards, err
+}
+
+// UserUpdate decodifica um evento "user_update"
+func (e Event) UserUpdate() (UserUpdate, error) {
+	var update UserUpdate
+	err := json.Unmarshal(e.Data, &update)
+	return update, err
+}
+
+// ServerRestarting decodifica um evento "server_restarting"
+func (e Event) ServerRestarting() (ServerRestarting, error) {
+	var notice ServerRestarting
+	err := json.Unmarshal(e.Data, &notice)
+	return notice, err
+}